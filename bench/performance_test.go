@@ -1,8 +1,10 @@
 package bench
 
 import (
+	"context"
 	"fmt"
 	"github.com/LENSHOOD/go-lock-free-ring-buffer"
+	"iter"
 	"math/rand"
 	"os"
 	"runtime"
@@ -41,6 +43,11 @@ func BenchmarkHybridMPMC(b *testing.B) {
 	mpmcBenchmark(b, mpscRB, threadNum, mpmcProducerNum)
 }
 
+func BenchmarkShardedMPMC(b *testing.B) {
+	shardedRB := lfring.New[int](lfring.Sharded, capacity)
+	mpmcBenchmark(b, shardedRB, threadNum, mpmcProducerNum)
+}
+
 func BenchmarkChannelMPMC(b *testing.B) {
 	fakeB := newFakeBuffer[int](capacity)
 	mpmcBenchmark(b, fakeB, threadNum, mpmcProducerNum)
@@ -83,9 +90,22 @@ func BenchmarkHybridSPSC(b *testing.B) {
 	spscBenchmark(b, mpscRB, 2, 1)
 }
 
+func BenchmarkSPSCOfferPoll(b *testing.B) {
+	runtime.GOMAXPROCS(2)
+	spscRB := lfring.New[int](lfring.SPSC, capacity)
+	mpmcBenchmark(b, spscRB, 2, 1)
+}
+
+func BenchmarkSPSC(b *testing.B) {
+	runtime.GOMAXPROCS(2)
+	spscRB := lfring.New[int](lfring.SPSC, capacity)
+	spscBenchmark(b, spscRB, 2, 1)
+}
+
 type fakeBuffer[T any] struct {
 	capacity uint64
 	ch       chan T
+	closed   uint32
 	empty    T
 }
 
@@ -105,6 +125,15 @@ func (r *fakeBuffer[T]) Offer(value T) (success bool) {
 	}
 }
 
+func (r *fakeBuffer[T]) OfferVec(values []T) (n int) {
+	for ; n < len(values); n++ {
+		if !r.Offer(values[n]) {
+			break
+		}
+	}
+	return
+}
+
 func (r *fakeBuffer[T]) Poll() (value T, success bool) {
 	select {
 	case v := <-r.ch:
@@ -114,6 +143,90 @@ func (r *fakeBuffer[T]) Poll() (value T, success bool) {
 	}
 }
 
+func (r *fakeBuffer[T]) PollVec(dst []T) (n int) {
+	for ; n < len(dst); n++ {
+		v, success := r.Poll()
+		if !success {
+			break
+		}
+		dst[n] = v
+	}
+	return
+}
+
+func (r *fakeBuffer[T]) OfferCtx(ctx context.Context, value T) error {
+	select {
+	case r.ch <- value:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *fakeBuffer[T]) PollCtx(ctx context.Context) (value T, err error) {
+	select {
+	case v, ok := <-r.ch:
+		if !ok {
+			return r.empty, lfring.ErrClosed
+		}
+		return v, nil
+	case <-ctx.Done():
+		return r.empty, ctx.Err()
+	}
+}
+
+func (r *fakeBuffer[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, success := r.Poll()
+			if !success || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func (r *fakeBuffer[T]) Stream(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, err := r.PollCtx(ctx)
+			if err != nil || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func (r *fakeBuffer[T]) Close() error {
+	atomic.StoreUint32(&r.closed, 1)
+	close(r.ch)
+	return nil
+}
+
+func (r *fakeBuffer[T]) IsClosed() bool {
+	return atomic.LoadUint32(&r.closed) != 0
+}
+
+func (r *fakeBuffer[T]) IsDrained() bool {
+	return r.IsClosed() && len(r.ch) == 0
+}
+
+func (r *fakeBuffer[T]) Len() uint64 {
+	return uint64(len(r.ch))
+}
+
+func (r *fakeBuffer[T]) Cap() uint64 {
+	return uint64(cap(r.ch))
+}
+
+func (r *fakeBuffer[T]) IsEmpty() bool {
+	return len(r.ch) == 0
+}
+
+func (r *fakeBuffer[T]) IsFull() bool {
+	return len(r.ch) == cap(r.ch)
+}
+
 func (r *fakeBuffer[T]) SingleProducerOffer(valueSupplier func() (v T, finish bool)) {
 	v, finish := valueSupplier()
 	if finish {
@@ -2,7 +2,7 @@
 
 do_bench() {
   go test -run "^$" -bench "^.+MPMC$" -benchtime=1s -count=100 | \
-    awk -v value="$1" '/NodeMPMC.+handovers/ || /HybridMPMC.+handovers/ || /ChannelMPMC.+handovers/ { printf "%s=(%d,%f)\n", $1, value, $5 }' \
+    awk -v value="$1" '/NodeMPMC.+handovers/ || /HybridMPMC.+handovers/ || /ShardedMPMC.+handovers/ || /ChannelMPMC.+handovers/ { printf "%s=(%d,%f)\n", $1, value, $5 }' \
     >> "$2"
 }
 
@@ -0,0 +1,123 @@
+package lfring
+
+import "context"
+
+// toChanBatch is the max number of values ToChan peeks at once.
+const toChanBatch = 64
+
+// FromChan starts a goroutine that offers every value received from src to dst, so that a
+// buffer can be placed in the middle of a channel pipeline.
+//
+// Once src has been closed and all values have been offered, dst will be closed, as the end
+// of stream. The goroutine also stops once ctx is done or dst has been closed by others, in
+// which case dst is left as is. If that happens while OfferCtx is waiting for room, the value
+// received from src but not offered is dropped, as a channel can't take it back, the rest are
+// left in src.
+//
+// The goroutine is a producer of dst, which closes dst by itself. So for SPSC, it must be the
+// only producer, and dst must not be closed by others while the goroutine runs.
+//
+// The returned channel receives nil or the reason why the goroutine stopped (ctx.Err() or
+// ErrClosed), then closed.
+func FromChan[T any](ctx context.Context, src <-chan T, dst RingBuffer[T]) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				done <- ctx.Err()
+				return
+			case v, ok := <-src:
+				if !ok {
+					done <- dst.Close()
+					return
+				}
+
+				if err := dst.OfferCtx(ctx, v); err != nil {
+					done <- err
+					return
+				}
+			}
+		}
+	}()
+
+	return done
+}
+
+// ToChan starts a goroutine that sends every value polled from src to the returned channel,
+// so that a buffer can be placed in the middle of a channel pipeline. The goroutine must be the
+// only consumer of src.
+//
+// If src is a Peeker, values are peeked in batch by PeekVec, and only polled (by
+// SingleConsumerPollVec) once they have been sent, so that nothing is lost when ctx is done:
+// the values not sent yet stay in src. Otherwise (Unbounded, Sharded, Overwrite and Growable),
+// values are polled one by one by PollCtx, and the one polled but not sent yet is dropped when
+// ctx is done.
+//
+// The returned channel is closed once src has been closed and drained, or ctx is done.
+func ToChan[T any](ctx context.Context, src RingBuffer[T]) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		if peeker, ok := src.(Peeker[T]); ok {
+			peekToChan(ctx, src, peeker, out)
+			return
+		}
+
+		for {
+			v, err := src.PollCtx(ctx)
+			if err != nil {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}()
+
+	return out
+}
+
+// strategyHolder is the buffer that holds a WaitStrategy, so that others can wait on it the
+// same way as its OfferCtx / PollCtx do.
+type strategyHolder interface {
+	strategy() WaitStrategy
+}
+
+// peekToChan sends the values peeked from src to out, and polls them after sent. It waits and
+// signals by the WaitStrategy of src, just like PollCtx.
+func peekToChan[T any](ctx context.Context, src RingBuffer[T], peeker Peeker[T], out chan<- T) {
+	strategy := defaultWaitStrategy
+	if holder, ok := src.(strategyHolder); ok {
+		strategy = holder.strategy()
+	}
+
+	batch := make([]T, toChanBatch)
+	for attempt := 0; ; {
+		n := peeker.PeekVec(batch)
+		if n == 0 {
+			if src.IsDrained() || strategy.Wait(ctx, attempt) != nil {
+				return
+			}
+			attempt++
+			continue
+		}
+
+		attempt = 0
+		for i, v := range batch[:n] {
+			select {
+			case <-ctx.Done():
+				src.SingleConsumerPollVec(batch[:i])
+				strategy.Signal()
+				return
+			case out <- v:
+			}
+		}
+		src.SingleConsumerPollVec(batch[:n])
+		strategy.Signal()
+	}
+}
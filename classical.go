@@ -1,28 +1,95 @@
 package lfring
 
 import (
+	"context"
+	"iter"
+	"runtime"
 	"sync/atomic"
+	"unsafe"
 )
 
+// classical is a ring buffer that moves head / tail by CAS, and stores values inline in
+// slots.
+//
+// Each slot has a published marker aside from its value, producer sets it after the value
+// has been written, and consumer resets it after the value has been read. So that a slot can
+// tell whether it's ready to be polled (or offered) without storing a pointer to the value,
+// which forces every offered value to escape to heap.
+//
+// Once padded, slots are spread by shift, so that each of them occupies at least one cache
+// line.
 type classical[T any] struct {
-	head     uint64
-	tail     uint64
-	capacity uint64
-	mask     uint64
-	element  []*T
+	head         uint64
+	tail         uint64
+	capacity     uint64
+	mask         uint64
+	limit        uint64
+	shift        uint64
+	element      []slot[T]
+	metrics      *Metrics
+	waitStrategy WaitStrategy
+	// guard the single-side methods, only works with build tag lfringdebug
+	producerGuard exclusiveGuard
+	consumerGuard exclusiveGuard
+	// the single handles handed out, see SingleProducerOf / SingleConsumerOf
+	owners sideOwners
+}
+
+type slot[T any] struct {
+	published uint32
+	// the number of Peek reading value, see Peek
+	peekers uint32
+	value   T
 }
 
-func newClassical[T any](capacity uint64) RingBuffer[T] {
+// newClassical build a classical buffer. As isFull keeps one slot empty, the buffer can hold
+// (capacity - 1) values, unless exact capacity is required, in which case one more slot will
+// be allocated.
+func newClassical[T any](capacity uint64, o *options) RingBuffer[T] {
+	realCapacity := findPowerOfTwo(capacity)
+	limit := realCapacity - 1
+	if o.exactCapacity {
+		realCapacity = findPowerOfTwo(capacity + 1)
+		limit = capacity
+	}
+
+	shift := slotShift[T](o)
 	return &classical[T]{
-		head:     uint64(0),
-		tail:     uint64(0),
-		capacity: capacity,
-		mask:     capacity - 1,
-		element:  make([]*T, capacity),
+		head:         uint64(0),
+		tail:         uint64(0),
+		capacity:     realCapacity,
+		mask:         realCapacity - 1,
+		limit:        limit,
+		shift:        shift,
+		element:      make([]slot[T], realCapacity<<shift),
+		metrics:      o.metrics,
+		waitStrategy: o.waitStrategy,
 	}
 }
 
+// slotShift returns how many bits the slot index shifts by, to make each slot occupy at least
+// one cache line once padded.
+func slotShift[T any](o *options) (shift uint64) {
+	if o.paddingOr(false) {
+		for unsafe.Sizeof(slot[T]{})<<shift < cacheLineSize {
+			shift++
+		}
+	}
+	return
+}
+
+// slotAt returns the slot of given head / tail
+func (r *classical[T]) slotAt(i uint64) *slot[T] {
+	return &r.element[(i&r.mask)<<r.shift]
+}
+
 func (r *classical[T]) Offer(value T) (success bool) {
+	success = r.offer(value)
+	r.metrics.recordOffer(boolToUint64(success))
+	return
+}
+
+func (r *classical[T]) offer(value T) (success bool) {
 	oldTail := atomic.LoadUint64(&r.tail)
 	oldHead := atomic.LoadUint64(&r.head)
 	if r.isFull(oldTail, oldHead) {
@@ -30,108 +97,343 @@ func (r *classical[T]) Offer(value T) (success bool) {
 	}
 
 	newTail := oldTail + 1
-	tailNode := r.element[newTail&r.mask]
-	// not published yet
-	if tailNode != nil {
+	tailSlot := r.slotAt(newTail)
+	// not polled yet
+	if atomic.LoadUint32(&tailSlot.published) != 0 {
 		return false
 	}
 	if !atomic.CompareAndSwapUint64(&r.tail, oldTail, newTail) {
 		return false
 	}
 
-	r.element[newTail&r.mask] = &value
+	tailSlot.value = value
+	atomic.StoreUint32(&tailSlot.published, 1)
 	return true
 }
 
-func (r *classical[T]) SingleProducerOffer(valueSupplier func() (v T, finish bool)) {
-	oldTail := r.tail
+// OfferVec offers values as many as possible, returns the number of values offered.
+//
+// Rather than claim one slot by CAS, we check how many slots after tail are ready to be
+// offered (both not full and polled), and claim all of them by one CAS. If CAS failed, nothing
+// will be offered, as same as Offer.
+func (r *classical[T]) OfferVec(values []T) (n int) {
+	n = r.offerVec(values)
+	r.metrics.recordOffer(uint64(n))
+	return
+}
+
+func (r *classical[T]) offerVec(values []T) int {
+	oldTail := atomic.LoadUint64(&r.tail)
 	oldHead := atomic.LoadUint64(&r.head)
 	if r.isFull(oldTail, oldHead) {
-		return
+		return 0
 	}
 
-	newTail := oldTail + 1
-	for ; newTail-oldHead < r.capacity; newTail++ {
-		tailNode := r.element[newTail&r.mask]
-		// not published yet
-		if tailNode != nil {
+	room := r.limit - (oldTail - oldHead)
+	if uint64(len(values)) < room {
+		room = uint64(len(values))
+	}
+
+	n := uint64(0)
+	for ; n < room; n++ {
+		// not polled yet
+		if atomic.LoadUint32(&r.slotAt(oldTail+n+1).published) != 0 {
+			break
+		}
+	}
+
+	if n == 0 || !atomic.CompareAndSwapUint64(&r.tail, oldTail, oldTail+n) {
+		return 0
+	}
+
+	for i := uint64(0); i < n; i++ {
+		tailSlot := r.slotAt(oldTail + i + 1)
+		tailSlot.value = values[i]
+		atomic.StoreUint32(&tailSlot.published, 1)
+	}
+	return int(n)
+}
+
+func (r *classical[T]) OfferCtx(ctx context.Context, value T) error {
+	return OfferWait[T](ctx, r, value, r.waitStrategy)
+}
+
+// SingleProducerOffer is the contention-free version of Offer, only one producer is allowed.
+//
+// As the only producer, we count the slots after tail that have been polled, and claim all of
+// them by a single CAS on tail up front, which never fails but for a concurrent Close. Then we
+// fill the claimed slots until the supplier finished, and give back the slots left by a single
+// add on tail. Consumers wait for each slot being published as usual, and never treat the
+// buffer as drained before the run is done.
+//
+// Close only sets closedFlag on tail, so we check it before filling each slot, that nothing
+// will be offered once Close returned. The value supplied during Close is discarded.
+func (r *classical[T]) SingleProducerOffer(valueSupplier func() (v T, finish bool)) {
+	r.producerGuard.enter("Classical.SingleProducerOffer")
+	defer r.producerGuard.exit()
+
+	oldTail := atomic.LoadUint64(&r.tail)
+	oldHead := atomic.LoadUint64(&r.head)
+	room := uint64(0)
+	// a closed tail is always seen as full
+	for ; !r.isFull(oldTail+room, oldHead); room++ {
+		// not polled yet
+		if atomic.LoadUint32(&r.slotAt(oldTail+room+1).published) != 0 {
 			break
 		}
+	}
+	if room == 0 || !atomic.CompareAndSwapUint64(&r.tail, oldTail, oldTail+room) {
+		r.metrics.recordOffer(0)
+		return
+	}
 
+	n := uint64(0)
+	for ; n < room; n++ {
 		v, finish := valueSupplier()
-		if finish {
+		// closed, maybe by the supplier itself
+		if finish || atomic.LoadUint64(&r.tail)&closedFlag != 0 {
 			break
 		}
-		r.element[newTail&r.mask] = &v
+		tailSlot := r.slotAt(oldTail + n + 1)
+		tailSlot.value = v
+		atomic.StoreUint32(&tailSlot.published, 1)
 	}
-	atomic.StoreUint64(&r.tail, newTail-1)
+	// add rather than store, to keep closedFlag
+	if n < room {
+		atomic.AddUint64(&r.tail, ^(room - n - 1))
+	}
+
+	r.metrics.recordOffer(n)
 }
 
 func (r *classical[T]) Poll() (value T, success bool) {
-	oldTail := atomic.LoadUint64(&r.tail)
+	value, success = r.poll()
+	r.metrics.recordPoll(boolToUint64(success))
+	return
+}
+
+func (r *classical[T]) poll() (value T, success bool) {
+	oldTail := atomic.LoadUint64(&r.tail) &^ closedFlag
 	oldHead := atomic.LoadUint64(&r.head)
 	if r.isEmpty(oldTail, oldHead) {
 		return
 	}
 
 	newHead := oldHead + 1
-	headNode := r.element[newHead&r.mask]
+	headSlot := r.slotAt(newHead)
 	// not published yet
-	if headNode == nil {
+	if atomic.LoadUint32(&headSlot.published) == 0 {
 		return
 	}
 	if !atomic.CompareAndSwapUint64(&r.head, oldHead, newHead) {
 		return
 	}
-	r.element[newHead&r.mask] = nil
 
-	return *headNode, true
+	return headSlot.take(), true
+}
+
+// PollVec polls at most len(dst) values into dst, returns the number of values polled.
+//
+// Same as OfferVec, we check how many slots after head are published, and claim all of them
+// by one CAS. If CAS failed, nothing will be polled, as same as Poll.
+func (r *classical[T]) PollVec(dst []T) (n int) {
+	n = r.pollVec(dst)
+	r.metrics.recordPoll(uint64(n))
+	return
+}
+
+func (r *classical[T]) pollVec(dst []T) int {
+	oldTail := atomic.LoadUint64(&r.tail) &^ closedFlag
+	oldHead := atomic.LoadUint64(&r.head)
+	if r.isEmpty(oldTail, oldHead) {
+		return 0
+	}
+
+	room := oldTail - oldHead
+	if uint64(len(dst)) < room {
+		room = uint64(len(dst))
+	}
+
+	n := uint64(0)
+	for ; n < room; n++ {
+		// not published yet
+		if atomic.LoadUint32(&r.slotAt(oldHead+n+1).published) == 0 {
+			break
+		}
+	}
+
+	if n == 0 || !atomic.CompareAndSwapUint64(&r.head, oldHead, oldHead+n) {
+		return 0
+	}
+
+	for i := uint64(0); i < n; i++ {
+		dst[i] = r.slotAt(oldHead + i + 1).take()
+	}
+	return int(n)
+}
+
+func (r *classical[T]) PollCtx(ctx context.Context) (value T, err error) {
+	return PollWait[T](ctx, r, r.waitStrategy)
+}
+
+func (r *classical[T]) All() iter.Seq[T] {
+	return all[T](r)
+}
+
+func (r *classical[T]) Stream(ctx context.Context) iter.Seq[T] {
+	return stream[T](ctx, r)
 }
 
 func (r *classical[T]) SingleConsumerPoll(valueConsumer func(T)) {
-	oldTail := atomic.LoadUint64(&r.tail)
+	r.consumerGuard.enter("Classical.SingleConsumerPoll")
+	defer r.consumerGuard.exit()
+
+	oldTail := atomic.LoadUint64(&r.tail) &^ closedFlag
 	oldHead := r.head
 	if r.isEmpty(oldTail, oldHead) {
+		r.metrics.recordPoll(0)
 		return
 	}
 
 	currHead := oldHead + 1
 	for ; currHead <= oldTail; currHead++ {
-		currNode := r.element[currHead&r.mask]
+		currSlot := r.slotAt(currHead)
 		// not published yet
-		if currNode == nil {
+		if atomic.LoadUint32(&currSlot.published) == 0 {
 			break
 		}
-		valueConsumer(*currNode)
-		r.element[currHead&r.mask] = nil
+		valueConsumer(currSlot.take())
 	}
 
 	atomic.StoreUint64(&r.head, currHead-1)
+	r.metrics.recordPoll(currHead - 1 - oldHead)
 }
 
 func (r *classical[T]) SingleConsumerPollVec(ret []T) (validCnt uint64) {
-	oldTail := atomic.LoadUint64(&r.tail)
+	r.consumerGuard.enter("Classical.SingleConsumerPollVec")
+	defer r.consumerGuard.exit()
+
+	oldTail := atomic.LoadUint64(&r.tail) &^ closedFlag
 	oldHead := r.head
 	if r.isEmpty(oldTail, oldHead) {
+		r.metrics.recordPoll(0)
 		return
 	}
 
 	currHead := oldHead + 1
-	for ; currHead <= oldTail; currHead++ {
-		currNode := r.element[currHead&r.mask]
+	for ; currHead <= oldTail && currHead-oldHead <= uint64(len(ret)); currHead++ {
+		currSlot := r.slotAt(currHead)
 		// not published yet
-		if currNode == nil {
+		if atomic.LoadUint32(&currSlot.published) == 0 {
 			break
 		}
-		ret[currHead-oldHead-1] = *currNode
-		r.element[currHead&r.mask] = nil
+		ret[currHead-oldHead-1] = currSlot.take()
 	}
 
 	atomic.StoreUint64(&r.head, currHead-1)
+	r.metrics.recordPoll(currHead - 1 - oldHead)
 
 	return currHead - oldHead - 1
 }
 
+// Peek reads the value of the slot after head, which is safe with multiple consumers. The slot
+// is pinned by peekers while we read, and the consumer that has taken it waits for peekers to
+// leave before clearing the value (so does the producer that offers to it next). So as long as
+// the slot is published and head hasn't moved after pinned, no consumer has taken the slot yet,
+// and the value stays intact until unpinned. Otherwise, we just read again.
+func (r *classical[T]) Peek() (value T, success bool) {
+	for {
+		oldTail := atomic.LoadUint64(&r.tail) &^ closedFlag
+		oldHead := atomic.LoadUint64(&r.head)
+		if r.isEmpty(oldTail, oldHead) {
+			return
+		}
+
+		headSlot := r.slotAt(oldHead + 1)
+		atomic.AddUint32(&headSlot.peekers, 1)
+		// not published yet, or taken
+		if atomic.LoadUint32(&headSlot.published) != 0 && atomic.LoadUint64(&r.head) == oldHead {
+			value, success = headSlot.value, true
+		}
+		atomic.AddUint32(&headSlot.peekers, ^uint32(0))
+
+		if success || atomic.LoadUint64(&r.head) == oldHead {
+			return
+		}
+	}
+}
+
+func (r *classical[T]) PeekVec(dst []T) (n int) {
+	r.consumerGuard.enter("Classical.PeekVec")
+	defer r.consumerGuard.exit()
+
+	oldTail := atomic.LoadUint64(&r.tail) &^ closedFlag
+	oldHead := r.head
+	for ; n < len(dst) && oldHead+uint64(n) < oldTail; n++ {
+		currSlot := r.slotAt(oldHead + uint64(n) + 1)
+		// not published yet
+		if atomic.LoadUint32(&currSlot.published) == 0 {
+			break
+		}
+		dst[n] = currSlot.value
+	}
+	return
+}
+
+func (r *classical[T]) Close() error {
+	if err := closeTail(&r.tail); err != nil {
+		return err
+	}
+
+	r.waitStrategy.Signal()
+	return nil
+}
+
+func (r *classical[T]) Len() uint64 {
+	return length(&r.tail, &r.head, r.Cap())
+}
+
+func (r *classical[T]) Cap() uint64 {
+	return r.limit
+}
+
+func (r *classical[T]) IsEmpty() bool {
+	return r.Len() == 0
+}
+
+func (r *classical[T]) IsFull() bool {
+	return r.Len() == r.Cap()
+}
+
+func (r *classical[T]) IsClosed() bool {
+	return atomic.LoadUint64(&r.tail)&closedFlag != 0
+}
+
+func (r *classical[T]) IsDrained() bool {
+	return drained(&r.tail, &r.head)
+}
+
+func (r *classical[T]) singleOwners() *sideOwners {
+	return &r.owners
+}
+
+func (r *classical[T]) strategy() WaitStrategy {
+	return r.waitStrategy
+}
+
+// take the value out of slot, then reset the slot to be offered again. The value will be
+// cleared to not hold any reference that prevents GC, once no Peek is reading it.
+func (s *slot[T]) take() (value T) {
+	value = s.value
+	for atomic.LoadUint32(&s.peekers) != 0 {
+		runtime.Gosched()
+	}
+	var empty T
+	s.value = empty
+	atomic.StoreUint32(&s.published, 0)
+	return
+}
+
 // isFull check whether buffer is full by compare (tail - head).
 // Because of none-sync read of tail and head, the tail maybe smaller than head(which is
 // never happened in the view of buffer):
@@ -142,8 +444,10 @@ func (r *classical[T]) SingleConsumerPollVec(ret []T) (validCnt uint64) {
 //
 // Hence, once tail < head means the tail is far behind the real (which means CAS-tail will
 // definitely fail), so we just return full to the Offer caller let it try again.
+//
+// A closed tail carries closedFlag, which also makes the buffer looks like full.
 func (r *classical[T]) isFull(tail uint64, head uint64) bool {
-	return tail-head >= r.capacity-1
+	return tail-head >= r.limit
 }
 
 // isEmpty check whether buffer is empty by compare (tail - head).
@@ -56,6 +56,499 @@ func (s *MySuite) TestHybridMpscVecConcurrencyRW(c *C) {
 	})
 }
 
+func (s *MySuite) TestNodeMpmcOfferVecConcurrencyRW(c *C) {
+	MPMCOfferVecConcurrencyRW(c, NodeBased, func(buffer RingBuffer[*string]) uint64 {
+		return atomic.LoadUint64(&buffer.(*nodeBased[*string]).head)
+	})
+}
+
+func (s *MySuite) TestHybridMpmcOfferVecConcurrencyRW(c *C) {
+	MPMCOfferVecConcurrencyRW(c, Classical, func(buffer RingBuffer[*string]) uint64 {
+		return atomic.LoadUint64(&buffer.(*classical[*string]).head)
+	})
+}
+
+func (s *MySuite) TestNodeMpmcPollVecConcurrencyRW(c *C) {
+	MPMCPollVecConcurrencyRW(c, NodeBased, func(buffer RingBuffer[*string]) uint64 {
+		return atomic.LoadUint64(&buffer.(*nodeBased[*string]).head)
+	})
+}
+
+func (s *MySuite) TestHybridMpmcPollVecConcurrencyRW(c *C) {
+	MPMCPollVecConcurrencyRW(c, Classical, func(buffer RingBuffer[*string]) uint64 {
+		return atomic.LoadUint64(&buffer.(*classical[*string]).head)
+	})
+}
+
+func (s *MySuite) TestUnboundedMpmcConcurrencyRW(c *C) {
+	MPMCConcurrencyRW(c, Unbounded, func(buffer RingBuffer[*string]) uint64 {
+		return buffer.(*unbounded[*string]).headIndex()
+	})
+}
+
+func (s *MySuite) TestUnboundedMpmcOfferVecConcurrencyRW(c *C) {
+	MPMCOfferVecConcurrencyRW(c, Unbounded, func(buffer RingBuffer[*string]) uint64 {
+		return buffer.(*unbounded[*string]).headIndex()
+	})
+}
+
+func (s *MySuite) TestUnboundedMpmcPollVecConcurrencyRW(c *C) {
+	MPMCPollVecConcurrencyRW(c, Unbounded, func(buffer RingBuffer[*string]) uint64 {
+		return buffer.(*unbounded[*string]).headIndex()
+	})
+}
+
+func (s *MySuite) TestShardedMpmcConcurrencyRW(c *C) {
+	MPMCConcurrencyRW(c, Sharded, func(buffer RingBuffer[*string]) (head uint64) {
+		for _, shard := range buffer.(*sharded[*string]).shards {
+			head += atomic.LoadUint64(&shard.head)
+		}
+		return
+	})
+}
+
+func (s *MySuite) TestShardedMpmcPollVecConcurrencyRW(c *C) {
+	MPMCPollVecConcurrencyRW(c, Sharded, func(buffer RingBuffer[*string]) (head uint64) {
+		for _, shard := range buffer.(*sharded[*string]).shards {
+			head += atomic.LoadUint64(&shard.head)
+		}
+		return
+	})
+}
+
+func (s *MySuite) TestSpscConcurrencyRW(c *C) {
+	SPSCConcurrencyRW(c, false)
+}
+
+func (s *MySuite) TestSpscSingleMethodsConcurrencyRW(c *C) {
+	SPSCConcurrencyRW(c, true)
+}
+
+func (s *MySuite) TestNarrowedMpscConcurrencyRW(c *C) {
+	// given
+	source := initDataSource()
+	buffer, _ := NewMPSC[*string](4)
+
+	var wg sync.WaitGroup
+	offer := func(from int) {
+		defer wg.Done()
+		for i := from; i < from+8; i++ {
+			for !buffer.Offer(&source[i]) {
+				runtime.Gosched()
+			}
+		}
+	}
+
+	// when
+	wg.Add(3)
+	go offer(0)
+	go offer(8)
+	go offer(16)
+
+	resultArr := make([]*string, 0, 24)
+	for len(resultArr) < 24 {
+		buffer.Drain(func(v *string) {
+			resultArr = append(resultArr, v)
+		})
+		runtime.Gosched()
+	}
+	wg.Wait()
+
+	// then
+	countSet := make(map[*string]int)
+	drainToSet(resultArr, countSet)
+	c.Assert(len(countSet), Equals, 24)
+}
+
+func (s *MySuite) TestNarrowedSpmcConcurrencyRW(c *C) {
+	// given
+	source := initDataSource()
+	buffer, _ := NewSPMC[*string](4)
+
+	var wg sync.WaitGroup
+	var polled int32
+	resultArrs := [3][]*string{}
+	consume := func(idx int) {
+		defer wg.Done()
+		for atomic.LoadInt32(&polled) < 24 {
+			if v, success := buffer.Poll(); success {
+				resultArrs[idx] = append(resultArrs[idx], v)
+				atomic.AddInt32(&polled, 1)
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}
+
+	// when
+	wg.Add(3)
+	go consume(0)
+	go consume(1)
+	go consume(2)
+
+	i := 0
+	for i < len(source) {
+		buffer.Fill(func() (v *string, finish bool) {
+			if i == len(source) {
+				return nil, true
+			}
+			i++
+			return &source[i-1], false
+		})
+		runtime.Gosched()
+	}
+	wg.Wait()
+
+	// then
+	countSet := make(map[*string]int)
+	for _, arr := range resultArrs {
+		drainToSet(arr, countSet)
+	}
+	c.Assert(len(countSet), Equals, 24)
+	for _, v := range countSet {
+		c.Assert(v, Equals, 1)
+	}
+}
+
+func (s *MySuite) TestOverwriteConcurrencyRW(c *C) {
+	// given
+	source := initDataSource()
+	buffer, _ := NewOverwrite[*string](4)
+
+	var wg sync.WaitGroup
+	offer := func(from int) {
+		defer wg.Done()
+		for i := from; i < from+8; i++ {
+			c.Check(buffer.Offer(&source[i]), Equals, true)
+			runtime.Gosched()
+		}
+	}
+
+	var done int32
+	var resultArr []*string
+	consumed := make(chan struct{})
+	go func() {
+		defer close(consumed)
+		for atomic.LoadInt32(&done) == 0 || !buffer.IsEmpty() {
+			if v, success := buffer.Poll(); success {
+				resultArr = append(resultArr, v)
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	// when
+	wg.Add(3)
+	go offer(0)
+	go offer(8)
+	go offer(16)
+	wg.Wait()
+	atomic.StoreInt32(&done, 1)
+	<-consumed
+
+	// then
+	countSet := make(map[*string]int)
+	drainToSet(resultArr, countSet)
+	c.Assert(uint64(len(resultArr))+buffer.Dropped(), Equals, uint64(24))
+	for _, v := range countSet {
+		c.Assert(v, Equals, 1)
+	}
+}
+
+func (s *MySuite) TestGrowableResizeConcurrencyRW(c *C) {
+	// given
+	source := initDataSource()
+	buffer, _ := NewGrowable[*string](2)
+
+	var wg sync.WaitGroup
+	offer := func(from int) {
+		defer wg.Done()
+		for i := from; i < from+8; i++ {
+			for !buffer.Offer(&source[i]) {
+				runtime.Gosched()
+			}
+		}
+	}
+
+	var polled int32
+	resultArrs := [2][]*string{}
+	consume := func(idx int) {
+		defer wg.Done()
+		for atomic.LoadInt32(&polled) < 24 {
+			if v, success := buffer.Poll(); success {
+				resultArrs[idx] = append(resultArrs[idx], v)
+				atomic.AddInt32(&polled, 1)
+			} else {
+				runtime.Gosched()
+			}
+		}
+	}
+
+	// when
+	wg.Add(5)
+	go offer(0)
+	go offer(8)
+	go offer(16)
+	go consume(0)
+	go consume(1)
+	for _, newCap := range []uint64{4, 8, 16} {
+		runtime.Gosched()
+		c.Assert(buffer.Resize(newCap), IsNil)
+	}
+	wg.Wait()
+
+	// then
+	countSet := make(map[*string]int)
+	for _, arr := range resultArrs {
+		drainToSet(arr, countSet)
+		// each consumer sees the values of each producer in order
+		last := map[int]int{0: -1, 8: -1, 16: -1}
+		for _, v := range arr {
+			idx := indexOf(source, v)
+			from := idx / 8 * 8
+			c.Assert(idx > last[from], Equals, true)
+			last[from] = idx
+		}
+	}
+	c.Assert(len(countSet), Equals, 24)
+	for _, v := range countSet {
+		c.Assert(v, Equals, 1)
+	}
+	c.Assert(buffer.Cap(), Equals, uint64(16))
+}
+
+func (s *MySuite) TestNodeOrderingHistory(c *C) {
+	OrderingHistoryCheck(c, New[int](NodeBased, 8), 4, 2)
+}
+
+func (s *MySuite) TestHybridOrderingHistory(c *C) {
+	OrderingHistoryCheck(c, New[int](Classical, 8), 4, 2)
+}
+
+func (s *MySuite) TestSpscOrderingHistory(c *C) {
+	OrderingHistoryCheck(c, New[int](SPSC, 8), 1, 1)
+}
+
+func (s *MySuite) TestUnboundedOrderingHistory(c *C) {
+	OrderingHistoryCheck(c, New[int](Unbounded, 4), 4, 2)
+}
+
+func (s *MySuite) TestGrowableOrderingHistory(c *C) {
+	OrderingHistoryCheck(c, New[int](Growable, 8), 4, 2)
+}
+
+// opRecord is the history of a value, in timestamps of a logical clock.
+type opRecord struct {
+	offerStart uint64
+	offerEnd   uint64
+	pollStart  uint64
+	pollEnd    uint64
+	polled     int32
+}
+
+// OrderingHistoryCheck records the offer / poll history of each value from many goroutines,
+// then validates the ordering guarantees documented on RingBuffer.
+func OrderingHistoryCheck(c *C, buffer RingBuffer[int], producers int, consumers int) {
+	// given
+	perProducer := 200
+	total := producers * perProducer
+	records := make([]opRecord, total)
+	var clock uint64
+
+	var wg sync.WaitGroup
+	offer := func(producer int) {
+		defer wg.Done()
+		for seq := 0; seq < perProducer; seq++ {
+			v := producer*perProducer + seq
+			for {
+				// only the successful attempt counts
+				records[v].offerStart = atomic.AddUint64(&clock, 1)
+				if buffer.Offer(v) {
+					break
+				}
+				runtime.Gosched()
+			}
+			records[v].offerEnd = atomic.AddUint64(&clock, 1)
+		}
+	}
+
+	var polledCnt int32
+	poll := func() {
+		defer wg.Done()
+		for atomic.LoadInt32(&polledCnt) < int32(total) {
+			start := atomic.AddUint64(&clock, 1)
+			v, success := buffer.Poll()
+			end := atomic.AddUint64(&clock, 1)
+			if !success {
+				runtime.Gosched()
+				continue
+			}
+
+			records[v].pollStart = start
+			records[v].pollEnd = end
+			atomic.AddInt32(&records[v].polled, 1)
+			atomic.AddInt32(&polledCnt, 1)
+		}
+	}
+
+	// when
+	wg.Add(producers + consumers)
+	for i := 0; i < consumers; i++ {
+		go poll()
+	}
+	for i := 0; i < producers; i++ {
+		go offer(i)
+	}
+	wg.Wait()
+
+	// then
+	for v := range records {
+		c.Assert(records[v].polled, Equals, int32(1), Commentf("value %d", v))
+	}
+	for x := range records {
+		for y := range records {
+			rx, ry := &records[x], &records[y]
+			// per-producer FIFO, or real-time FIFO
+			offeredBefore := (x/perProducer == y/perProducer && x < y) || rx.offerEnd < ry.offerStart
+			if offeredBefore && ry.pollEnd < rx.pollStart {
+				c.Fatalf("%d offered before %d, but polled after it", x, y)
+			}
+		}
+	}
+}
+
+func (s *MySuite) TestPeekConcurrencyRW(c *C) {
+	for _, t := range bufferSet {
+		// given
+		total := 1000
+		buffer := New[int](t, 4)
+		peeker := buffer.(Peeker[int])
+		go func() {
+			for i := 0; i < total; i++ {
+				for !buffer.Offer(i) {
+					runtime.Gosched()
+				}
+			}
+		}()
+
+		// when
+		for i := 0; i < total; {
+			peeked, success := peeker.Peek()
+			if !success {
+				runtime.Gosched()
+				continue
+			}
+
+			// then
+			polled, _ := buffer.Poll()
+			c.Assert(peeked, Equals, i)
+			c.Assert(polled, Equals, i)
+			i++
+		}
+	}
+}
+
+func (s *MySuite) TestPeekConcurrencyMultiConsumers(c *C) {
+	for _, t := range []BufferType{NodeBased, Classical} {
+		// given
+		producers, consumers, perProducer := 2, 4, 1000
+		buffer := New[[2]int](t, 4)
+		peeker := buffer.(Peeker[[2]int])
+		var polledCnt int32
+		var wg sync.WaitGroup
+		wg.Add(producers + consumers)
+		for p := 0; p < producers; p++ {
+			go func() {
+				defer wg.Done()
+				for i := 1; i <= perProducer; i++ {
+					for !buffer.Offer([2]int{i, -i}) {
+						runtime.Gosched()
+					}
+				}
+			}()
+		}
+
+		// when
+		var torn int32
+		for i := 0; i < consumers; i++ {
+			go func() {
+				defer wg.Done()
+				for atomic.LoadInt32(&polledCnt) < int32(producers*perProducer) {
+					if v, success := peeker.Peek(); success && (v[0] <= 0 || v[0] != -v[1]) {
+						atomic.AddInt32(&torn, 1)
+					}
+					if _, success := buffer.Poll(); success {
+						atomic.AddInt32(&polledCnt, 1)
+					} else {
+						runtime.Gosched()
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		// then
+		c.Assert(torn, Equals, int32(0))
+		c.Assert(polledCnt, Equals, int32(producers*perProducer))
+	}
+}
+
+func (s *MySuite) TestZeroCopyConcurrencyRW(c *C) {
+	// given
+	producers, perProducer := 4, 500
+	buffer := New[[2]int](NodeBased, 8)
+	zc := buffer.(ZeroCopy[[2]int])
+	for p := 0; p < producers; p++ {
+		go func(p int) {
+			for i := 0; i < perProducer; {
+				slot, seq, ok := zc.Claim()
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				slot[0], slot[1] = p, i
+				zc.Publish(seq)
+				i++
+			}
+		}(p)
+	}
+
+	// when
+	var mu sync.Mutex
+	seen := make(map[[2]int]int)
+	var wg sync.WaitGroup
+	var polled int64
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt64(&polled) < int64(producers*perProducer) {
+				slot, seq, ok := zc.Acquire()
+				if !ok {
+					runtime.Gosched()
+					continue
+				}
+				v := *slot
+				zc.Release(seq)
+				atomic.AddInt64(&polled, 1)
+
+				mu.Lock()
+				seen[v]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// then
+	c.Assert(len(seen), Equals, producers*perProducer)
+	for v, n := range seen {
+		c.Assert(n, Equals, 1, Commentf("value %v", v))
+	}
+	c.Assert(buffer.IsEmpty(), Equals, true)
+}
+
 func MPMCConcurrencyRW(c *C, t BufferType, getHead func(buffer RingBuffer[*string]) uint64) {
 	// given
 	source := initDataSource()
@@ -388,6 +881,201 @@ func MPSCVecConcurrencyRW(c *C, t BufferType, getHead func(buffer RingBuffer[*st
 	}
 }
 
+func MPMCOfferVecConcurrencyRW(c *C, t BufferType, getHead func(buffer RingBuffer[*string]) uint64) {
+	// given
+	source := initDataSource()
+
+	capacity := 4
+	buffer := New[*string](t, uint64(capacity))
+
+	var wg sync.WaitGroup
+	offerVec := func(buffer RingBuffer[*string], from int) {
+		defer wg.Done()
+		values := make([]*string, 8)
+		for i := range values {
+			values[i] = &source[from+i]
+		}
+		for len(values) > 0 {
+			batch := values
+			if len(batch) > 3 {
+				batch = batch[:3]
+			}
+			values = values[buffer.OfferVec(batch):]
+		}
+	}
+
+	var finishWg sync.WaitGroup
+	consumer := func(buffer RingBuffer[*string], ch chan struct{}, outputArr []*string) {
+		counter := 0
+		for {
+			select {
+			case <-ch:
+				finishWg.Done()
+				return
+			default:
+				if poll, success := buffer.Poll(); success {
+					outputArr[counter] = poll
+					counter++
+				}
+			}
+		}
+	}
+
+	// when
+	done := make(chan struct{})
+	finishWg.Add(3)
+	resultArr1 := make([]*string, 24)
+	resultArr2 := make([]*string, 24)
+	resultArr3 := make([]*string, 24)
+	go consumer(buffer, done, resultArr1)
+	go consumer(buffer, done, resultArr2)
+	go consumer(buffer, done, resultArr3)
+
+	wg.Add(3)
+	go offerVec(buffer, 0)
+	go offerVec(buffer, 8)
+	go offerVec(buffer, 16)
+
+	wg.Wait()
+	for getHead(buffer) < 24 {
+		runtime.Gosched()
+	}
+	close(done)
+	finishWg.Wait()
+
+	// then
+	countSet := make(map[*string]int)
+	drainToSet(resultArr1, countSet)
+	drainToSet(resultArr2, countSet)
+	drainToSet(resultArr3, countSet)
+	c.Assert(len(countSet), Equals, 24)
+	for _, v := range countSet {
+		c.Assert(v, Equals, 1)
+	}
+}
+
+func MPMCPollVecConcurrencyRW(c *C, t BufferType, getHead func(buffer RingBuffer[*string]) uint64) {
+	// given
+	source := initDataSource()
+
+	capacity := 4
+	buffer := New[*string](t, uint64(capacity))
+
+	var wg sync.WaitGroup
+	offer := func(buffer RingBuffer[*string], from int) {
+		defer wg.Done()
+		for i := from; i < from+8; i++ {
+			v := source[i]
+			for !buffer.Offer(&v) {
+			}
+		}
+	}
+
+	var finishWg sync.WaitGroup
+	consumer := func(buffer RingBuffer[*string], ch chan struct{}, outputArr []*string) {
+		counter := 0
+		dst := make([]*string, 3)
+		for {
+			select {
+			case <-ch:
+				finishWg.Done()
+				return
+			default:
+				n := buffer.PollVec(dst)
+				counter += copy(outputArr[counter:], dst[:n])
+			}
+		}
+	}
+
+	// when
+	done := make(chan struct{})
+	finishWg.Add(3)
+	resultArr1 := make([]*string, 24)
+	resultArr2 := make([]*string, 24)
+	resultArr3 := make([]*string, 24)
+	go consumer(buffer, done, resultArr1)
+	go consumer(buffer, done, resultArr2)
+	go consumer(buffer, done, resultArr3)
+
+	wg.Add(3)
+	go offer(buffer, 0)
+	go offer(buffer, 8)
+	go offer(buffer, 16)
+
+	wg.Wait()
+	for getHead(buffer) < 24 {
+		runtime.Gosched()
+	}
+	close(done)
+	finishWg.Wait()
+
+	// then
+	countSet := make(map[*string]int)
+	drainToSet(resultArr1, countSet)
+	drainToSet(resultArr2, countSet)
+	drainToSet(resultArr3, countSet)
+	c.Assert(len(countSet), Equals, 24)
+	for _, v := range countSet {
+		c.Assert(v, Equals, 1)
+	}
+}
+
+func SPSCConcurrencyRW(c *C, single bool) {
+	// given
+	capacity := 4
+	total := 1000
+	buffer := New[int](SPSC, uint64(capacity))
+
+	producer := func() {
+		if !single {
+			for i := 0; i < total; i++ {
+				for !buffer.Offer(i) {
+					runtime.Gosched()
+				}
+			}
+			return
+		}
+
+		i := 0
+		for i < total {
+			buffer.SingleProducerOffer(func() (v int, finish bool) {
+				if i == total {
+					return 0, true
+				}
+				i++
+				return i - 1, false
+			})
+			runtime.Gosched()
+		}
+	}
+
+	// when
+	go producer()
+	result := make([]int, 0, total)
+	ret := make([]int, 3)
+	for len(result) < total {
+		if !single {
+			if v, success := buffer.Poll(); success {
+				result = append(result, v)
+			} else {
+				runtime.Gosched()
+			}
+			continue
+		}
+
+		validCnt := buffer.SingleConsumerPollVec(ret)
+		result = append(result, ret[:validCnt]...)
+		if validCnt == 0 {
+			runtime.Gosched()
+		}
+	}
+
+	// then
+	for i, v := range result {
+		c.Assert(v, Equals, i)
+	}
+}
+
 func drainToSet(srcArr []*string, descSet map[*string]int) {
 	for i := 0; i < len(srcArr); i++ {
 		if srcArr[i] != nil {
@@ -400,6 +1088,15 @@ func drainToSet(srcArr []*string, descSet map[*string]int) {
 	}
 }
 
+func indexOf(source []string, v *string) int {
+	for i := range source {
+		if &source[i] == v {
+			return i
+		}
+	}
+	return -1
+}
+
 func initDataSource() []string {
 	sourceArray := make([]string, 24)
 	for i := 0; i < 24; i++ {
@@ -1,8 +1,13 @@
 package lfring
 
 import (
+	"context"
+	"errors"
 	. "gopkg.in/check.v1"
+	"runtime"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // hook up go-check to go testing
@@ -35,7 +40,7 @@ func (s *MySuite) TestFindPowerOfTwo(c *C) {
 	c.Assert(res5, Equals, uint64(0))
 }
 
-var bufferSet = []BufferType{NodeBased, Classical}
+var bufferSet = []BufferType{NodeBased, Classical, SPSC}
 
 func (s *MySuite) TestOfferAndPollSuccess(c *C) {
 	for _, t := range bufferSet {
@@ -127,3 +132,1344 @@ func (s *MySuite) TestRingBufferShift(c *C) {
 		c.Assert(polled2, Equals, 16)
 	}
 }
+
+func (s *MySuite) TestOfferCtxTimeoutWhenFull(c *C) {
+	for _, t := range bufferSet {
+		// given
+		capacity := 10
+		buffer := New[int](t, uint64(capacity))
+		for buffer.Offer(0) {
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+		// when
+		err := buffer.OfferCtx(ctx, 1)
+		cancel()
+
+		// then
+		c.Assert(err, Equals, context.DeadlineExceeded)
+	}
+}
+
+func (s *MySuite) TestPollCtxCanceledWhenEmpty(c *C) {
+	for _, t := range bufferSet {
+		// given
+		capacity := 10
+		buffer := New[int](t, uint64(capacity))
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		// when
+		_, err := buffer.PollCtx(ctx)
+
+		// then
+		c.Assert(err, Equals, context.Canceled)
+	}
+}
+
+func (s *MySuite) TestPollCtxWaitUntilOffered(c *C) {
+	for _, t := range bufferSet {
+		// given
+		capacity := 10
+		buffer := New[int](t, uint64(capacity))
+		time.AfterFunc(10*time.Millisecond, func() {
+			_ = buffer.OfferCtx(context.Background(), 7)
+		})
+
+		// when
+		polled, err := buffer.PollCtx(context.Background())
+
+		// then
+		c.Assert(err, IsNil)
+		c.Assert(polled, Equals, 7)
+	}
+}
+
+func (s *MySuite) TestOfferFailedWhenClosed(c *C) {
+	for _, t := range bufferSet {
+		// given
+		capacity := 10
+		buffer := New[int](t, uint64(capacity))
+
+		// when
+		closeErr := buffer.Close()
+		offered := buffer.Offer(1)
+		offerCtxErr := buffer.OfferCtx(context.Background(), 1)
+
+		// then
+		c.Assert(closeErr, IsNil)
+		c.Assert(offered, Equals, false)
+		c.Assert(offerCtxErr, Equals, ErrClosed)
+		c.Assert(buffer.Close(), Equals, ErrClosed)
+	}
+}
+
+func (s *MySuite) TestPollDrainAfterClosed(c *C) {
+	for _, t := range bufferSet {
+		// given
+		capacity := 10
+		buffer := New[int](t, uint64(capacity))
+		for i := 0; i < 3; i++ {
+			buffer.Offer(i)
+		}
+
+		// when
+		_ = buffer.Close()
+
+		// then
+		polled, success := buffer.Poll()
+		c.Assert(success, Equals, true)
+		c.Assert(polled, Equals, 0)
+		for i := 1; i < 3; i++ {
+			polled, err := buffer.PollCtx(context.Background())
+			c.Assert(err, IsNil)
+			c.Assert(polled, Equals, i)
+		}
+
+		_, success = buffer.Poll()
+		c.Assert(success, Equals, false)
+		_, err := buffer.PollCtx(context.Background())
+		c.Assert(err, Equals, ErrClosed)
+	}
+}
+
+func (s *MySuite) TestIsClosedAndIsDrained(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[int](t, 4)
+		buffer.Offer(1)
+		openDrained := buffer.IsDrained()
+
+		// when
+		_ = buffer.Close()
+		closed, closedDrained := buffer.IsClosed(), buffer.IsDrained()
+		_, success := buffer.Poll()
+		_, emptySuccess := buffer.Poll()
+
+		// then
+		c.Assert(openDrained, Equals, false)
+		c.Assert(closed, Equals, true)
+		c.Assert(closedDrained, Equals, false)
+		c.Assert(success, Equals, true)
+		c.Assert(emptySuccess, Equals, false)
+		c.Assert(buffer.IsDrained(), Equals, true)
+	}
+}
+
+func (s *MySuite) TestSingleProducerOfferStopWhenClosed(c *C) {
+	for _, t := range bufferSet {
+		// given
+		capacity := 4
+		buffer := New[int](t, uint64(capacity))
+		_ = buffer.Close()
+
+		// when
+		supplied := 0
+		buffer.SingleProducerOffer(func() (v int, finish bool) {
+			supplied++
+			return supplied, supplied > 10
+		})
+
+		// then
+		_, success := buffer.Poll()
+		c.Assert(success, Equals, false)
+	}
+}
+
+func (s *MySuite) TestSingleProducerOfferClosedBySupplier(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[int](t, 8)
+		supplied := 0
+
+		// when
+		buffer.SingleProducerOffer(func() (v int, finish bool) {
+			supplied++
+			if supplied == 3 {
+				_ = buffer.Close()
+			}
+			return supplied, supplied > 6
+		})
+
+		// then
+		var polled []int
+		for {
+			v, err := buffer.PollCtx(context.Background())
+			if err != nil {
+				c.Assert(err, Equals, ErrClosed)
+				break
+			}
+			polled = append(polled, v)
+		}
+		c.Assert(supplied, Equals, 3)
+		c.Assert(polled, DeepEquals, []int{1, 2})
+		c.Assert(buffer.Len(), Equals, uint64(0))
+		_, success := buffer.Poll()
+		c.Assert(success, Equals, false)
+	}
+}
+
+func (s *MySuite) TestLenAndCap(c *C) {
+	for _, t := range bufferSet {
+		// given
+		capacity := 10
+		buffer := New[int](t, uint64(capacity))
+
+		// then
+		c.Assert(buffer.Len(), Equals, uint64(0))
+		c.Assert(buffer.IsEmpty(), Equals, true)
+		c.Assert(buffer.IsFull(), Equals, false)
+
+		// when
+		offered := uint64(0)
+		for buffer.Offer(0) {
+			offered++
+		}
+
+		// then
+		c.Assert(buffer.Cap(), Equals, offered)
+		c.Assert(buffer.Len(), Equals, offered)
+		c.Assert(buffer.IsEmpty(), Equals, false)
+		c.Assert(buffer.IsFull(), Equals, true)
+
+		// when
+		buffer.Poll()
+		_ = buffer.Close()
+
+		// then
+		c.Assert(buffer.Len(), Equals, offered-1)
+		c.Assert(buffer.IsFull(), Equals, false)
+	}
+}
+
+func (s *MySuite) TestSingleProducerOfferReturnWhenFull(c *C) {
+	for _, t := range bufferSet {
+		// given
+		capacity := 4
+		buffer := New[int](t, uint64(capacity))
+
+		// when
+		supplied := 0
+		buffer.SingleProducerOffer(func() (v int, finish bool) {
+			supplied++
+			return supplied, false
+		})
+
+		// then
+		c.Assert(uint64(supplied), Equals, buffer.Cap())
+		c.Assert(buffer.IsFull(), Equals, true)
+		for i := 1; i <= supplied; i++ {
+			polled, success := buffer.Poll()
+			c.Assert(success, Equals, true)
+			c.Assert(polled, Equals, i)
+		}
+	}
+}
+
+func (s *MySuite) TestSingleConsumerPollInOrder(c *C) {
+	for _, t := range bufferSet {
+		// given
+		capacity := 4
+		buffer := New[int](t, uint64(capacity))
+		for i := 0; buffer.Offer(i); i++ {
+		}
+		buffer.Poll()
+		buffer.Offer(int(buffer.Cap()))
+
+		// when
+		var polled []int
+		buffer.SingleConsumerPoll(func(v int) {
+			polled = append(polled, v)
+		})
+
+		// then
+		c.Assert(uint64(len(polled)), Equals, buffer.Cap())
+		for i, v := range polled {
+			c.Assert(v, Equals, i+1)
+		}
+		c.Assert(buffer.IsEmpty(), Equals, true)
+	}
+}
+
+type largeValue struct {
+	id      int
+	name    string
+	payload [16]uint64
+}
+
+func (s *MySuite) TestOfferAndPollWithoutAllocation(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[largeValue](t, 16)
+		value := largeValue{id: 1, name: "fake"}
+
+		// when
+		allocs := testing.AllocsPerRun(100, func() {
+			buffer.Offer(value)
+			buffer.Poll()
+		})
+
+		// then
+		c.Assert(allocs, Equals, float64(0))
+	}
+}
+
+func (s *MySuite) TestNewWithInvalidParameters(c *C) {
+	// when
+	_, unknownTypeErr := NewE[int](BufferType(-1), 16)
+	_, zeroCapErr := NewE[int](Classical, 0)
+	_, oneCapErr := NewE[int](Classical, 1)
+	_, hugeCapErr := NewE[int](NodeBased, MaxCapacity+1)
+
+	// then
+	c.Assert(errors.Is(unknownTypeErr, ErrUnknownBufferType), Equals, true)
+	c.Assert(errors.Is(zeroCapErr, ErrInvalidCapacity), Equals, true)
+	c.Assert(errors.Is(oneCapErr, ErrInvalidCapacity), Equals, true)
+	c.Assert(errors.Is(hugeCapErr, ErrInvalidCapacity), Equals, true)
+	c.Assert(func() { New[int](BufferType(-1), 16) }, PanicMatches, ".*unknown buffer type.*")
+}
+
+func (s *MySuite) TestNewWithMaxCapacity(c *C) {
+	for t := Classical; t <= Sharded; t++ {
+		for _, opts := range [][]Option{nil, {WithExactCapacity()}} {
+			// when
+			_, err := NewE[int](t, MaxCapacity, opts...)
+
+			// then
+			c.Assert(errors.Is(err, ErrInvalidCapacity), Equals, true)
+		}
+	}
+
+	// given
+	buffer, _ := NewGrowable[int](16)
+
+	// then
+	c.Assert(errors.Is(buffer.Resize(MaxCapacity), ErrInvalidCapacity), Equals, true)
+}
+
+func (s *MySuite) TestNewWithPaddingTooLarge(c *C) {
+	// when
+	_, err := NewE[int](Classical, 1<<42, WithCacheLinePadding(true))
+
+	// then
+	c.Assert(errors.Is(err, ErrInvalidCapacity), Equals, true)
+}
+
+func (s *MySuite) TestNewWithMinCapacity(c *C) {
+	for _, t := range bufferSet {
+		// when
+		buffer, err := NewE[int](t, MinCapacity)
+
+		// then
+		c.Assert(err, IsNil)
+		c.Assert(buffer.Offer(1), Equals, true)
+		polled, success := buffer.Poll()
+		c.Assert(success, Equals, true)
+		c.Assert(polled, Equals, 1)
+	}
+}
+
+func (s *MySuite) TestExactCapacity(c *C) {
+	for _, t := range bufferSet {
+		// given
+		capacity := 10
+		buffer := New[int](t, uint64(capacity), WithExactCapacity())
+
+		// when
+		offered := 0
+		for buffer.Offer(offered) {
+			offered++
+		}
+
+		// then
+		c.Assert(offered, Equals, capacity)
+		c.Assert(buffer.Cap(), Equals, uint64(capacity))
+		c.Assert(buffer.IsFull(), Equals, true)
+
+		// when
+		buffer.Poll()
+		supplied := 0
+		buffer.SingleProducerOffer(func() (v int, finish bool) {
+			supplied++
+			return supplied, false
+		})
+
+		// then
+		c.Assert(supplied, Equals, 1)
+		c.Assert(buffer.Len(), Equals, uint64(capacity))
+	}
+}
+
+func (s *MySuite) TestCacheLinePadding(c *C) {
+	for _, t := range bufferSet {
+		for _, padding := range []bool{true, false} {
+			// given
+			buffer := New[int](t, 4, WithCacheLinePadding(padding))
+
+			// when
+			for i := 0; i < 10; i++ {
+				buffer.Offer(i)
+				polled, success := buffer.Poll()
+
+				// then
+				c.Assert(success, Equals, true)
+				c.Assert(polled, Equals, i)
+			}
+		}
+	}
+}
+
+func (s *MySuite) TestMetrics(c *C) {
+	for _, t := range bufferSet {
+		// given
+		metrics := &Metrics{}
+		buffer := New[int](t, 4, WithMetrics(metrics))
+
+		// when
+		for buffer.Offer(0) {
+		}
+		buffer.SingleConsumerPoll(func(int) {})
+		buffer.Poll()
+
+		// then
+		c.Assert(metrics.Offered(), Equals, buffer.Cap())
+		c.Assert(metrics.OfferFailures(), Equals, uint64(1))
+		c.Assert(metrics.Polled(), Equals, buffer.Cap())
+		c.Assert(metrics.PollFailures(), Equals, uint64(1))
+	}
+}
+
+type countingWait struct {
+	waits   int
+	signals int
+}
+
+func (w *countingWait) Wait(ctx context.Context, attempt int) error {
+	w.waits++
+	if attempt >= 2 {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func (w *countingWait) Signal() {
+	w.signals++
+}
+
+func (s *MySuite) TestWaitStrategy(c *C) {
+	for _, t := range bufferSet {
+		// given
+		strategy := &countingWait{}
+		buffer := New[int](t, 4, WithWaitStrategy(strategy))
+
+		// when
+		_, pollErr := buffer.PollCtx(context.Background())
+		offerErr := buffer.OfferCtx(context.Background(), 1)
+
+		// then
+		c.Assert(pollErr, Equals, context.DeadlineExceeded)
+		c.Assert(offerErr, IsNil)
+		c.Assert(strategy.waits, Equals, 3)
+		c.Assert(strategy.signals, Equals, 1)
+	}
+}
+
+func (s *MySuite) TestBuiltinWaitStrategies(c *C) {
+	strategies := []WaitStrategy{
+		BusySpin{},
+		Yielding{},
+		BackoffSleep{Min: time.Microsecond, Max: time.Millisecond},
+		NewBlocking(time.Millisecond),
+		&Blocking{},
+	}
+	for _, t := range bufferSet {
+		for _, strategy := range strategies {
+			// given
+			buffer := New[int](t, 4)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+			// when
+			_, timeoutErr := PollWait[int](ctx, buffer, strategy)
+			offerErr := OfferWait[int](context.Background(), buffer, 1, strategy)
+			polled, pollErr := PollWait[int](context.Background(), buffer, strategy)
+			cancel()
+
+			// then
+			c.Assert(timeoutErr, Equals, context.DeadlineExceeded)
+			c.Assert(offerErr, IsNil)
+			c.Assert(pollErr, IsNil)
+			c.Assert(polled, Equals, 1)
+		}
+	}
+}
+
+func (s *MySuite) TestBlockingWakeUpBySignal(c *C) {
+	for _, t := range bufferSet {
+		// given
+		strategy := NewBlocking(time.Minute)
+		buffer := New[int](t, 4, WithWaitStrategy(strategy))
+		start := time.Now()
+		go func() {
+			for atomic.LoadInt32(&strategy.waiters) == 0 {
+				runtime.Gosched()
+			}
+			_ = buffer.OfferCtx(context.Background(), 1)
+		}()
+
+		// when
+		polled, err := buffer.PollCtx(context.Background())
+
+		// then
+		c.Assert(err, IsNil)
+		c.Assert(polled, Equals, 1)
+		c.Assert(time.Since(start) < time.Minute, Equals, true)
+	}
+}
+
+func (s *MySuite) TestBlockingZeroValue(c *C) {
+	// given
+	strategy := &Blocking{}
+	strategy.Signal()
+	buffer := New[int](NodeBased, 4, WithWaitStrategy(strategy))
+	go func() {
+		for atomic.LoadInt32(&strategy.waiters) == 0 {
+			runtime.Gosched()
+		}
+		_ = buffer.OfferCtx(context.Background(), 1)
+		_ = buffer.Close()
+	}()
+
+	// when
+	polled, err := buffer.PollCtx(context.Background())
+	_, closedErr := buffer.PollCtx(context.Background())
+
+	// then
+	c.Assert(err, IsNil)
+	c.Assert(polled, Equals, 1)
+	c.Assert(closedErr, Equals, ErrClosed)
+}
+
+func (s *MySuite) TestBackoffInterval(c *C) {
+	// when
+	first := backoffInterval(time.Microsecond, time.Millisecond, 0)
+	third := backoffInterval(time.Microsecond, time.Millisecond, 2)
+	last := backoffInterval(time.Microsecond, time.Millisecond, 100)
+
+	// then
+	c.Assert(first, Equals, time.Microsecond)
+	c.Assert(third, Equals, 4*time.Microsecond)
+	c.Assert(last, Equals, time.Millisecond)
+}
+
+func (s *MySuite) TestBackoffIntervalDefaults(c *C) {
+	// when
+	zero := backoffInterval(0, 0, 0)
+	zeroLast := backoffInterval(0, 0, 100)
+	inverted := backoffInterval(time.Millisecond, time.Microsecond, 100)
+
+	// then
+	c.Assert(zero, Equals, minPark)
+	c.Assert(zeroLast, Equals, maxPark)
+	c.Assert(inverted, Equals, time.Millisecond)
+}
+
+func (s *MySuite) TestBackoffSleepZeroValueSleeps(c *C) {
+	// given
+	strategy := BackoffSleep{}
+	waits := 20
+	start := time.Now()
+
+	// when
+	for i := 0; i < waits; i++ {
+		_ = strategy.Wait(context.Background(), 100)
+	}
+
+	// then
+	c.Assert(time.Since(start) >= time.Duration(waits)*maxPark, Equals, true)
+}
+
+func (s *MySuite) TestOfferVec(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[int](t, 10)
+		values := make([]int, 20)
+		for i := range values {
+			values[i] = i
+		}
+		buffer.Offer(-1)
+		buffer.Poll()
+
+		// when
+		offered := buffer.OfferVec(values)
+
+		// then
+		c.Assert(uint64(offered), Equals, buffer.Cap())
+		c.Assert(buffer.OfferVec(values[offered:]), Equals, 0)
+		for i := 0; i < offered; i++ {
+			polled, success := buffer.Poll()
+			c.Assert(success, Equals, true)
+			c.Assert(polled, Equals, i)
+		}
+	}
+}
+
+func (s *MySuite) TestOfferVecWithExactCapacity(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[int](t, 10, WithExactCapacity())
+		buffer.Offer(0)
+
+		// when
+		offered := buffer.OfferVec(make([]int, 20))
+
+		// then
+		c.Assert(offered, Equals, 9)
+		c.Assert(buffer.IsFull(), Equals, true)
+	}
+}
+
+func (s *MySuite) TestPollVec(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[int](t, 10)
+		for i := 0; buffer.Offer(i); i++ {
+		}
+		dst := make([]int, 4)
+
+		// when
+		polled := buffer.PollVec(dst)
+
+		// then
+		c.Assert(polled, Equals, len(dst))
+		c.Assert(dst, DeepEquals, []int{0, 1, 2, 3})
+
+		// when
+		all := make([]int, 20)
+		polled = buffer.PollVec(all)
+
+		// then
+		c.Assert(uint64(polled), Equals, buffer.Cap()-4)
+		c.Assert(all[0], Equals, 4)
+		c.Assert(buffer.PollVec(all), Equals, 0)
+	}
+}
+
+func (s *MySuite) TestSingleConsumerPollVecNotOverrun(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[int](t, 10)
+		for i := 0; buffer.Offer(i); i++ {
+		}
+		ret := make([]int, 4)
+
+		// when
+		validCnt := buffer.SingleConsumerPollVec(ret)
+
+		// then
+		c.Assert(validCnt, Equals, uint64(len(ret)))
+		c.Assert(ret, DeepEquals, []int{0, 1, 2, 3})
+		c.Assert(buffer.Len(), Equals, buffer.Cap()-4)
+	}
+}
+
+func (s *MySuite) TestNarrowedBuffers(c *C) {
+	// given
+	mpscBuffer, mpscErr := NewMPSC[int](4)
+	spmcBuffer, spmcErr := NewSPMC[int](4)
+	_, invalidErr := NewMPSC[int](0)
+
+	// then
+	c.Assert(mpscErr, IsNil)
+	c.Assert(spmcErr, IsNil)
+	c.Assert(errors.Is(invalidErr, ErrInvalidCapacity), Equals, true)
+	_, isRingBuffer := interface{}(mpscBuffer).(RingBuffer[int])
+	c.Assert(isRingBuffer, Equals, false)
+	_, isRingBuffer = interface{}(spmcBuffer).(RingBuffer[int])
+	c.Assert(isRingBuffer, Equals, false)
+
+	// when
+	i := 0
+	spmcBuffer.Fill(func() (v int, finish bool) {
+		i++
+		return i, false
+	})
+	for v, success := spmcBuffer.Poll(); success; v, success = spmcBuffer.Poll() {
+		mpscBuffer.Offer(v)
+	}
+	var drained []int
+	mpscBuffer.Drain(func(v int) {
+		drained = append(drained, v)
+	})
+
+	// then
+	c.Assert(drained, DeepEquals, []int{1, 2, 3})
+	c.Assert(spmcBuffer.IsEmpty(), Equals, true)
+	c.Assert(mpscBuffer.IsEmpty(), Equals, true)
+}
+
+func (s *MySuite) TestProducerAndConsumerHandles(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[int](t, 4)
+		producer := SingleProducerOf(buffer)
+		consumer := SingleConsumerOf(buffer)
+
+		// when
+		producer.Offer(0)
+		producer.OfferVec([]int{1, 2})
+		i := 3
+		producer.SingleProducerOffer(func() (v int, finish bool) {
+			i++
+			return i - 1, i > 4
+		})
+		ret := make([]int, 2)
+		validCnt := consumer.SingleConsumerPollVec(ret)
+		var polled []int
+		consumer.SingleConsumerPoll(func(v int) {
+			polled = append(polled, v)
+		})
+
+		// then
+		c.Assert(validCnt, Equals, uint64(2))
+		c.Assert(ret, DeepEquals, []int{0, 1})
+		c.Assert(polled[0], Equals, 2)
+		_, isRingBuffer := interface{}(ProducerOf(buffer)).(RingBuffer[int])
+		c.Assert(isRingBuffer, Equals, false)
+		_, isRingBuffer = interface{}(ConsumerOf(buffer)).(RingBuffer[int])
+		c.Assert(isRingBuffer, Equals, false)
+	}
+}
+
+func (s *MySuite) TestSingleHandlesOnlyOnce(c *C) {
+	for _, t := range []BufferType{NodeBased, Classical, SPSC, Overwrite, Unbounded, Sharded} {
+		// given
+		buffer := New[int](t, 4)
+		SingleProducerOf(buffer)
+		SingleConsumerOf(buffer)
+
+		// then
+		c.Assert(func() { SingleProducerOf(buffer) }, PanicMatches, "lfring: SingleProducerOf called twice.*")
+		c.Assert(func() { SingleConsumerOf(buffer) }, PanicMatches, "lfring: SingleConsumerOf called twice.*")
+		c.Assert(ProducerOf(buffer), NotNil)
+		c.Assert(ConsumerOf(buffer), NotNil)
+	}
+}
+
+func (s *MySuite) TestOverwriteEvictsOldest(c *C) {
+	// given
+	metrics := &Metrics{}
+	buffer, err := NewOverwrite[int](4, WithMetrics(metrics))
+	c.Assert(err, IsNil)
+
+	// when
+	for i := 0; i < 10; i++ {
+		c.Assert(buffer.Offer(i), Equals, true)
+	}
+	n := buffer.OfferVec([]int{10, 11})
+	ret := make([]int, 8)
+	validCnt := buffer.SingleConsumerPollVec(ret)
+
+	// then
+	c.Assert(n, Equals, 2)
+	c.Assert(ret[:validCnt], DeepEquals, []int{8, 9, 10, 11})
+	c.Assert(buffer.Dropped(), Equals, uint64(8))
+	c.Assert(metrics.Dropped(), Equals, uint64(8))
+	c.Assert(metrics.Offered(), Equals, uint64(12))
+}
+
+func (s *MySuite) TestOverwriteFailsOnlyWhenClosed(c *C) {
+	// given
+	buffer := New[int](Overwrite, 2)
+	buffer.Offer(1)
+	buffer.Offer(2)
+	_ = buffer.Close()
+
+	// when
+	success := buffer.Offer(3)
+	offerErr := buffer.OfferCtx(context.Background(), 3)
+	var polled []int
+	buffer.SingleConsumerPoll(func(v int) {
+		polled = append(polled, v)
+	})
+	_, pollErr := buffer.PollCtx(context.Background())
+
+	// then
+	c.Assert(success, Equals, false)
+	c.Assert(offerErr, Equals, ErrClosed)
+	c.Assert(polled, DeepEquals, []int{1, 2})
+	c.Assert(pollErr, Equals, ErrClosed)
+}
+
+func (s *MySuite) TestUnboundedNeverFull(c *C) {
+	// given
+	buffer := New[int](Unbounded, 4)
+
+	for round := 0; round < 3; round++ {
+		// when
+		for i := 0; i < 10; i++ {
+			c.Assert(buffer.Offer(i), Equals, true)
+		}
+		n := buffer.OfferVec([]int{10, 11, 12, 13, 14, 15})
+
+		// then
+		c.Assert(n, Equals, 6)
+		c.Assert(buffer.Len(), Equals, uint64(16))
+		c.Assert(buffer.IsFull(), Equals, false)
+		for i := 0; i < 16; i++ {
+			v, success := buffer.Poll()
+			c.Assert(success, Equals, true)
+			c.Assert(v, Equals, i)
+		}
+		_, success := buffer.Poll()
+		c.Assert(success, Equals, false)
+		c.Assert(buffer.IsEmpty(), Equals, true)
+	}
+}
+
+func (s *MySuite) TestUnboundedClose(c *C) {
+	// given
+	buffer := New[int](Unbounded, 2)
+	buffer.OfferVec([]int{1, 2, 3})
+
+	// when
+	closeErr := buffer.Close()
+	success := buffer.Offer(4)
+	offerErr := buffer.OfferCtx(context.Background(), 4)
+	var polled []int
+	for v, pollErr := buffer.PollCtx(context.Background()); pollErr == nil; v, pollErr = buffer.PollCtx(context.Background()) {
+		polled = append(polled, v)
+	}
+
+	// then
+	c.Assert(closeErr, IsNil)
+	c.Assert(buffer.Close(), Equals, ErrClosed)
+	c.Assert(success, Equals, false)
+	c.Assert(offerErr, Equals, ErrClosed)
+	c.Assert(polled, DeepEquals, []int{1, 2, 3})
+}
+
+func (s *MySuite) TestGrowableResize(c *C) {
+	// given
+	buffer, err := NewGrowable[int](4)
+	c.Assert(err, IsNil)
+	n := buffer.OfferVec([]int{0, 1, 2, 3, 4})
+	c.Assert(n, Equals, 4)
+	c.Assert(buffer.IsFull(), Equals, true)
+
+	// when
+	resizeErr := buffer.Resize(8)
+	n = buffer.OfferVec([]int{4, 5, 6, 7, 8})
+
+	// then
+	c.Assert(resizeErr, IsNil)
+	c.Assert(n, Equals, 4)
+	c.Assert(buffer.Cap(), Equals, uint64(8))
+	c.Assert(buffer.Len(), Equals, uint64(8))
+	c.Assert(buffer.Offer(8), Equals, false)
+	ret := make([]int, 8)
+	for i := 0; i < 8; i++ {
+		v, success := buffer.Poll()
+		c.Assert(success, Equals, true)
+		ret[i] = v
+	}
+	c.Assert(ret, DeepEquals, []int{0, 1, 2, 3, 4, 5, 6, 7})
+	c.Assert(buffer.IsEmpty(), Equals, true)
+}
+
+func (s *MySuite) TestGrowableResizeInvalid(c *C) {
+	// given
+	buffer, _ := NewGrowable[int](8)
+
+	// when
+	shrinkErr := buffer.Resize(4)
+	sameErr := buffer.Resize(5)
+	invalidErr := buffer.Resize(0)
+	_ = buffer.Close()
+	closedErr := buffer.Resize(16)
+
+	// then
+	c.Assert(errors.Is(shrinkErr, ErrInvalidCapacity), Equals, true)
+	c.Assert(sameErr, IsNil)
+	c.Assert(buffer.Cap(), Equals, uint64(8))
+	c.Assert(errors.Is(invalidErr, ErrInvalidCapacity), Equals, true)
+	c.Assert(closedErr, Equals, ErrClosed)
+}
+
+func (s *MySuite) TestPriorityRingStrictPriority(c *C) {
+	// given
+	ring, err := NewPriorityRing[int](NodeBased, 4, []uint64{0, 0, 0})
+	c.Assert(err, IsNil)
+	ring.Offer(2, 20)
+	ring.Offer(1, 10)
+	ring.Offer(2, 21)
+	ring.Offer(0, 0)
+
+	// when
+	var polled, priorities []int
+	for v, priority, success := ring.PollPriority(); success; v, priority, success = ring.PollPriority() {
+		polled = append(polled, v)
+		priorities = append(priorities, priority)
+	}
+
+	// then
+	c.Assert(polled, DeepEquals, []int{0, 10, 20, 21})
+	c.Assert(priorities, DeepEquals, []int{0, 1, 2, 2})
+	c.Assert(ring.Lanes(), Equals, 3)
+	c.Assert(ring.Cap(), Equals, uint64(12))
+}
+
+func (s *MySuite) TestPriorityRingWeightedRoundRobin(c *C) {
+	// given
+	ring, _ := NewPriorityRing[int](Classical, 16, []uint64{3, 1})
+	for i := 0; i < 8; i++ {
+		ring.Offer(0, 0)
+		ring.Offer(1, 1)
+	}
+
+	// when
+	polled := make([]int, 0, 8)
+	for i := 0; i < 8; i++ {
+		v, _ := ring.Poll()
+		polled = append(polled, v)
+	}
+
+	// then
+	c.Assert(polled, DeepEquals, []int{0, 0, 1, 0, 0, 0, 1, 0})
+	c.Assert(ring.Len(), Equals, uint64(8))
+}
+
+func (s *MySuite) TestPriorityRingInvalidAndClose(c *C) {
+	// given
+	_, noLaneErr := NewPriorityRing[int](NodeBased, 4, nil)
+	_, tooHeavyErr := NewPriorityRing[int](NodeBased, 4, []uint64{1 << 16, 1})
+	_, capacityErr := NewPriorityRing[int](NodeBased, 0, []uint64{1})
+	ring, _ := NewPriorityRing[int](NodeBased, 4, []uint64{1, 1})
+	ring.Offer(1, 1)
+
+	// when
+	closeErr := ring.Close()
+	v, pollErr := ring.PollCtx(context.Background())
+	_, drainedErr := ring.PollCtx(context.Background())
+
+	// then
+	c.Assert(errors.Is(noLaneErr, ErrInvalidWeights), Equals, true)
+	c.Assert(errors.Is(tooHeavyErr, ErrInvalidWeights), Equals, true)
+	c.Assert(errors.Is(capacityErr, ErrInvalidCapacity), Equals, true)
+	c.Assert(closeErr, IsNil)
+	c.Assert(ring.Close(), Equals, ErrClosed)
+	c.Assert(ring.Offer(0, 0), Equals, false)
+	c.Assert(v, Equals, 1)
+	c.Assert(pollErr, IsNil)
+	c.Assert(drainedErr, Equals, ErrClosed)
+}
+
+func (s *MySuite) TestShardedOfferAndPollAll(c *C) {
+	// given
+	buffer := New[int](Sharded, 16, WithShards(3), WithExactCapacity())
+	c.Assert(buffer.Cap(), Equals, uint64(16))
+	c.Assert(len(buffer.(*sharded[int]).shards), Equals, 3)
+
+	// when
+	for i := 0; i < 10; i++ {
+		c.Assert(buffer.Offer(i), Equals, true)
+	}
+	n := buffer.OfferVec([]int{10, 11, 12, 13, 14, 15, 16})
+	polled := make(map[int]bool)
+	dst := make([]int, 5)
+	for cnt := buffer.PollVec(dst); cnt > 0; cnt = buffer.PollVec(dst) {
+		for _, v := range dst[:cnt] {
+			polled[v] = true
+		}
+	}
+
+	// then
+	c.Assert(n, Equals, 6)
+	c.Assert(len(polled), Equals, 16)
+	c.Assert(buffer.IsEmpty(), Equals, true)
+}
+
+func (s *MySuite) TestShardedCapacitySharedByShards(c *C) {
+	// when
+	rounded := New[int](Sharded, 1000, WithShards(12))
+	exact := New[int](Sharded, 1000, WithShards(12), WithExactCapacity())
+	small := New[int](Sharded, 16, WithShards(3))
+	offered := 0
+	for small.Offer(offered) {
+		offered++
+	}
+
+	// then
+	c.Assert(rounded.Cap(), Equals, uint64(1024))
+	c.Assert(exact.Cap(), Equals, uint64(1000))
+	c.Assert(small.Cap(), Equals, uint64(16))
+	c.Assert(offered, Equals, 16)
+	c.Assert(small.IsFull(), Equals, true)
+}
+
+func (s *MySuite) TestShardedShardsLimitedByCapacity(c *C) {
+	// given
+	buffer := New[int](Sharded, 4, WithShards(8))
+
+	// then
+	c.Assert(len(buffer.(*sharded[int]).shards), Equals, 2)
+	c.Assert(buffer.Cap(), Equals, uint64(4))
+}
+
+func (s *MySuite) TestShardedSingleMethodsAndClose(c *C) {
+	// given
+	buffer := New[int](Sharded, 8, WithShards(2))
+	i := 0
+	buffer.SingleProducerOffer(func() (v int, finish bool) {
+		i++
+		return i - 1, i > 10
+	})
+
+	// when
+	closeErr := buffer.Close()
+	var polled []int
+	buffer.SingleConsumerPoll(func(v int) {
+		polled = append(polled, v)
+	})
+	_, pollErr := buffer.PollCtx(context.Background())
+
+	// then
+	c.Assert(i, Equals, 8)
+	c.Assert(closeErr, IsNil)
+	c.Assert(buffer.Offer(0), Equals, false)
+	c.Assert(len(polled), Equals, 8)
+	c.Assert(pollErr, Equals, ErrClosed)
+}
+
+func (s *MySuite) TestChanBridges(c *C) {
+	for _, t := range []BufferType{NodeBased, Classical, SPSC} {
+		// given
+		src := make(chan int)
+		buffer := New[int](t, 4)
+		fromDone := FromChan(context.Background(), src, buffer)
+		out := ToChan(context.Background(), buffer)
+
+		// when
+		go func() {
+			for i := 0; i < 100; i++ {
+				src <- i
+			}
+			close(src)
+		}()
+		var received []int
+		for v := range out {
+			received = append(received, v)
+		}
+
+		// then
+		c.Assert(len(received), Equals, 100)
+		for i, v := range received {
+			c.Assert(v, Equals, i)
+		}
+		c.Assert(<-fromDone, IsNil)
+	}
+}
+
+func (s *MySuite) TestChanBridgesStop(c *C) {
+	// given
+	buffer := New[int](NodeBased, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	src := make(chan int)
+	fromDone := FromChan(ctx, src, buffer)
+	out := ToChan(ctx, buffer)
+	closedBuffer := New[int](NodeBased, 4)
+	_ = closedBuffer.Close()
+	closedSrc := make(chan int, 1)
+	closedSrc <- 1
+
+	// when
+	src <- 1
+	v := <-out
+	cancel()
+	_, open := <-out
+	closedErr := <-FromChan(context.Background(), closedSrc, closedBuffer)
+
+	// then
+	c.Assert(v, Equals, 1)
+	c.Assert(open, Equals, false)
+	c.Assert(<-fromDone, Equals, context.Canceled)
+	c.Assert(closedErr, Equals, ErrClosed)
+}
+
+func (s *MySuite) TestFromChanDropsOnlyValueInHandOnCancel(c *C) {
+	// given
+	buffer := New[int](NodeBased, 4)
+	src := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		src <- i
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := FromChan(ctx, src, buffer)
+	// buffer full, and the fifth value is waiting for room
+	for len(src) > 5 {
+		runtime.Gosched()
+	}
+
+	// when
+	cancel()
+	err := <-done
+	var offered []int
+	for v := range buffer.All() {
+		offered = append(offered, v)
+	}
+
+	// then
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(offered, DeepEquals, []int{0, 1, 2, 3})
+	c.Assert(len(src), Equals, 5)
+	c.Assert(<-src, Equals, 5)
+}
+
+func (s *MySuite) TestToChanKeepsValuesOnCancel(c *C) {
+	for _, t := range []BufferType{NodeBased, Classical, SPSC} {
+		// given
+		buffer := New[int](t, 16)
+		for i := 0; i < 10; i++ {
+			buffer.Offer(i)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		out := ToChan(ctx, buffer)
+
+		// when
+		received := []int{<-out, <-out, <-out}
+		cancel()
+		for v := range out {
+			received = append(received, v)
+		}
+		for v := range buffer.All() {
+			received = append(received, v)
+		}
+
+		// then
+		c.Assert(received, DeepEquals, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	}
+}
+
+func (s *MySuite) TestToChanWaitsByBufferStrategy(c *C) {
+	for _, t := range []BufferType{NodeBased, Classical, SPSC} {
+		// given
+		strategy := &countingWait{}
+		buffer := New[int](t, 4, WithWaitStrategy(strategy))
+		buffer.Offer(1)
+
+		// when
+		var received []int
+		for v := range ToChan(context.Background(), buffer) {
+			received = append(received, v)
+		}
+
+		// then
+		c.Assert(received, DeepEquals, []int{1})
+		c.Assert(strategy.waits, Equals, 3)
+		c.Assert(strategy.signals, Equals, 1)
+	}
+}
+
+func (s *MySuite) TestSelectFairly(c *C) {
+	// given
+	bufs := []RingBuffer[int]{New[int](NodeBased, 16), New[int](Classical, 16), New[int](SPSC, 16)}
+	for i, buffer := range bufs {
+		for j := 0; j < 8; j++ {
+			buffer.Offer(i)
+		}
+	}
+
+	// when
+	counts := make([]int, len(bufs))
+	for j := 0; j < 12; j++ {
+		idx, v, err := Select(context.Background(), bufs...)
+		c.Assert(err, IsNil)
+		c.Assert(v, Equals, idx)
+		counts[idx]++
+	}
+
+	// then
+	c.Assert(counts, DeepEquals, []int{4, 4, 4})
+}
+
+func (s *MySuite) TestSelectWaitAndClose(c *C) {
+	// given
+	bufs := []RingBuffer[int]{New[int](NodeBased, 4), New[int](NodeBased, 4)}
+	go func() {
+		time.Sleep(time.Millisecond)
+		bufs[1].Offer(1)
+	}()
+
+	// when
+	idx, v, err := Select(context.Background(), bufs...)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, _, timeoutErr := Select(ctx, bufs...)
+	_ = bufs[0].Close()
+	_ = bufs[1].Close()
+	_, _, closedErr := Select(context.Background(), bufs...)
+
+	// then
+	c.Assert(err, IsNil)
+	c.Assert(idx, Equals, 1)
+	c.Assert(v, Equals, 1)
+	c.Assert(timeoutErr, Equals, context.DeadlineExceeded)
+	c.Assert(closedErr, Equals, ErrClosed)
+}
+
+func (s *MySuite) TestSelectFunc(c *C) {
+	// given
+	ints := New[int](NodeBased, 4)
+	strs := New[string](Classical, 4)
+	strs.Offer("a")
+	var polledStr string
+	var polledInt int
+
+	// when
+	idx, err := SelectFunc(context.Background(),
+		Case(ints, func(v int) { polledInt = v }),
+		Case(strs, func(v string) { polledStr = v }),
+	)
+
+	// then
+	c.Assert(err, IsNil)
+	c.Assert(idx, Equals, 1)
+	c.Assert(polledStr, Equals, "a")
+	c.Assert(polledInt, Equals, 0)
+}
+
+func (s *MySuite) TestAllDrainsUntilEmpty(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[int](t, 8)
+		buffer.OfferVec([]int{0, 1, 2, 3, 4})
+
+		// when
+		var first []int
+		for v := range buffer.All() {
+			first = append(first, v)
+			if v == 1 {
+				break
+			}
+		}
+		var rest []int
+		for v := range SingleConsumerOf(buffer).All() {
+			rest = append(rest, v)
+		}
+
+		// then
+		c.Assert(first, DeepEquals, []int{0, 1})
+		c.Assert(rest, DeepEquals, []int{2, 3, 4})
+		c.Assert(buffer.IsEmpty(), Equals, true)
+	}
+}
+
+func (s *MySuite) TestStreamUntilClosed(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[int](t, 4)
+		go func() {
+			for i := 0; i < 20; i++ {
+				for !buffer.Offer(i) {
+					runtime.Gosched()
+				}
+			}
+			_ = buffer.Close()
+		}()
+
+		// when
+		var streamed []int
+		for v := range SingleConsumerOf(buffer).Stream(context.Background()) {
+			streamed = append(streamed, v)
+		}
+
+		// then
+		c.Assert(len(streamed), Equals, 20)
+		for i, v := range streamed {
+			c.Assert(v, Equals, i)
+		}
+	}
+}
+
+func (s *MySuite) TestStreamStopsOnCtxDone(c *C) {
+	// given
+	mpscBuffer, _ := NewMPSC[int](4)
+	mpscBuffer.Offer(1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	// when
+	var streamed []int
+	for v := range mpscBuffer.Stream(ctx) {
+		streamed = append(streamed, v)
+	}
+
+	// then
+	c.Assert(streamed, DeepEquals, []int{1})
+	c.Assert(ctx.Err(), Equals, context.DeadlineExceeded)
+}
+
+func (s *MySuite) TestPeekWithoutPolling(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[int](t, 8)
+		peeker := buffer.(Peeker[int])
+		_, emptyPeeked := peeker.Peek()
+		buffer.OfferVec([]int{0, 1, 2})
+
+		// when
+		v, success := peeker.Peek()
+		dst := make([]int, 4)
+		n := peeker.PeekVec(dst)
+		polled, _ := buffer.Poll()
+		afterPoll, _ := peeker.Peek()
+
+		// then
+		c.Assert(emptyPeeked, Equals, false)
+		c.Assert(success, Equals, true)
+		c.Assert(v, Equals, 0)
+		c.Assert(n, Equals, 3)
+		c.Assert(dst[:n], DeepEquals, []int{0, 1, 2})
+		c.Assert(polled, Equals, 0)
+		c.Assert(afterPoll, Equals, 1)
+		c.Assert(buffer.Len(), Equals, uint64(2))
+	}
+}
+
+func (s *MySuite) TestZeroCopyInPlace(c *C) {
+	// given
+	type frame struct {
+		id      int
+		payload [16]int
+	}
+	buffer := New[frame](NodeBased, 2, WithExactCapacity())
+	zc := buffer.(ZeroCopy[frame])
+	_, _, emptyAcquired := zc.Acquire()
+
+	// when
+	slot, seq, claimed := zc.Claim()
+	slot.id = 1
+	slot.payload[15] = 42
+	_, _, unpublishedAcquired := zc.Acquire()
+	zc.Publish(seq)
+	c.Assert(buffer.Offer(frame{id: 2}), Equals, true)
+	_, _, fullClaimed := zc.Claim()
+	acquired, acquiredSeq, success := zc.Acquire()
+	got := *acquired
+	zc.Release(acquiredSeq)
+	polled, _ := buffer.Poll()
+	_ = buffer.Close()
+	_, _, closedClaimed := zc.Claim()
+
+	// then
+	c.Assert(emptyAcquired, Equals, false)
+	c.Assert(claimed, Equals, true)
+	c.Assert(unpublishedAcquired, Equals, false)
+	c.Assert(fullClaimed, Equals, false)
+	c.Assert(success, Equals, true)
+	c.Assert(acquiredSeq, Equals, seq)
+	c.Assert(got.id, Equals, 1)
+	c.Assert(got.payload[15], Equals, 42)
+	c.Assert(polled.id, Equals, 2)
+	c.Assert(closedClaimed, Equals, false)
+	c.Assert(buffer.IsEmpty(), Equals, true)
+}
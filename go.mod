@@ -1,6 +1,6 @@
 module github.com/LENSHOOD/go-lock-free-ring-buffer
 
-go 1.18
+go 1.23
 
 require (
 	github.com/go-echarts/go-echarts/v2 v2.2.4
@@ -0,0 +1,77 @@
+package lfring
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// GrowableBuffer is a bounded ring buffer whose capacity can grow online, while producers and
+// consumers keep running.
+type GrowableBuffer[T any] interface {
+	RingBuffer[T]
+	// Resize grows the capacity to newCap, without losing or reordering any value. It returns
+	// an error wraps ErrInvalidCapacity if newCap is less than the current capacity or out of
+	// [MinCapacity, MaxCapacity], or ErrClosed if the buffer has been closed.
+	Resize(newCap uint64) error
+}
+
+// NewGrowable build a GrowableBuffer, which is backed by a chain of NodeBased buffers.
+func NewGrowable[T any](capacity uint64, opts ...Option) (GrowableBuffer[T], error) {
+	buffer, err := NewE[T](Growable, capacity, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.(GrowableBuffer[T]), nil
+}
+
+func newGrowable[T any](capacity uint64, o *options) RingBuffer[T] {
+	r := newUnbounded[T](capacity, o).(*unbounded[T])
+	r.growable = true
+	return r
+}
+
+// Resize seals the tail segment, and appends a new segment with newCap to it. Just like
+// Unbounded, producers move to the new segment once they find the tail sealed, while
+// consumers drain the sealed segment first, then move forward, so nothing will be reordered.
+//
+// As the new segment starts from where the sealed one ends, head / tail keep growing across
+// segments, so that the capacity is still checked against (tail - head), including the
+// values remaining in the sealed segment.
+//
+// Resize is serialized by a lock, but never blocks the producers and consumers.
+func (r *unbounded[T]) Resize(newCap uint64) error {
+	if err := checkCapacity[T](Growable, newCap, r.segmentOpts); err != nil {
+		return err
+	}
+
+	limit := newCap
+	if !r.segmentOpts.exactCapacity {
+		limit = findPowerOfTwo(newCap)
+	}
+
+	r.resizeMu.Lock()
+	defer r.resizeMu.Unlock()
+
+	if r.IsClosed() {
+		return ErrClosed
+	}
+	if limit < r.Cap() {
+		return fmt.Errorf("%w: %d, can only grow from %d", ErrInvalidCapacity, newCap, r.Cap())
+	}
+	if limit == r.Cap() {
+		return nil
+	}
+
+	// new segments are built by the new capacity since now
+	atomic.StoreUint64(&r.segmentCap, limit)
+	seg := r.acquire(&r.tail)
+	_ = closeTail(&seg.ring.tail)
+	next := r.appendNext(seg, nil)
+	r.release(seg)
+
+	if next == r.sentinel {
+		return ErrClosed
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !lfringdebug
+
+package lfring
+
+// exclusiveGuard does nothing without build tag lfringdebug, see guard_debug.go.
+type exclusiveGuard struct{}
+
+func (g *exclusiveGuard) enter(string) {}
+
+func (g *exclusiveGuard) exit() {}
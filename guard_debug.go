@@ -0,0 +1,25 @@
+//go:build lfringdebug
+
+package lfring
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// exclusiveGuard detects concurrent entry of the methods that must be called from one
+// goroutine at a time, it panics once detected. Only enabled with build tag lfringdebug.
+type exclusiveGuard struct {
+	inUse int32
+}
+
+func (g *exclusiveGuard) enter(method string) {
+	if !atomic.CompareAndSwapInt32(&g.inUse, 0, 1) {
+		panic(fmt.Sprintf("lfring: concurrent call of single-side method %s, "+
+			"which must be called by only one goroutine at a time", method))
+	}
+}
+
+func (g *exclusiveGuard) exit() {
+	atomic.StoreInt32(&g.inUse, 0)
+}
@@ -0,0 +1,57 @@
+//go:build lfringdebug
+
+package lfring
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestSingleConsumerPanicOnConcurrentUse(c *C) {
+	// given
+	buffer := New[int](NodeBased, 4)
+	buffer.Offer(1)
+	consumer := SingleConsumerOf(buffer)
+
+	// when
+	reentrant := func() {
+		consumer.SingleConsumerPoll(func(int) {
+			consumer.Poll()
+		})
+	}
+
+	// then
+	c.Assert(reentrant, PanicMatches, "lfring: concurrent call of single-side method SingleConsumer.Poll.*")
+}
+
+func (s *MySuite) TestSingleSideMethodsPanicOnConcurrentUse(c *C) {
+	for _, t := range bufferSet {
+		// given
+		buffer := New[int](t, 4)
+		buffer.Offer(1)
+
+		// when
+		reentrantPoll := func() {
+			buffer.SingleConsumerPoll(func(int) {
+				buffer.SingleConsumerPollVec(make([]int, 1))
+			})
+		}
+		peekBuffer := New[int](t, 4)
+		peekBuffer.Offer(1)
+		reentrantPeek := func() {
+			peekBuffer.SingleConsumerPoll(func(int) {
+				peekBuffer.(Peeker[int]).PeekVec(make([]int, 1))
+			})
+		}
+		reentrantOffer := func() {
+			buffer.SingleProducerOffer(func() (int, bool) {
+				buffer.SingleProducerOffer(func() (int, bool) { return 0, true })
+				return 0, true
+			})
+		}
+
+		// then
+		c.Assert(reentrantPoll, PanicMatches, "lfring: concurrent call of single-side method .*SingleConsumerPollVec.*")
+		c.Assert(reentrantPeek, PanicMatches, "lfring: concurrent call of single-side method .*PeekVec.*")
+		c.Assert(reentrantOffer, PanicMatches, "lfring: concurrent call of single-side method .*SingleProducerOffer.*")
+	}
+}
@@ -0,0 +1,232 @@
+package lfring
+
+import (
+	"context"
+	"iter"
+	"sync/atomic"
+)
+
+// Producer is the producer side of a RingBuffer, can be shared among many producers.
+type Producer[T any] interface {
+	Offer(T) (success bool)
+	OfferVec(values []T) (n int)
+	OfferCtx(ctx context.Context, value T) error
+	Close() error
+	IsClosed() bool
+}
+
+// Consumer is the consumer side of a RingBuffer, can be shared among many consumers.
+type Consumer[T any] interface {
+	Poll() (value T, success bool)
+	PollVec(dst []T) (n int)
+	PollCtx(ctx context.Context) (value T, err error)
+	All() iter.Seq[T]
+	Stream(ctx context.Context) iter.Seq[T]
+	IsDrained() bool
+}
+
+// SingleProducer is the exclusive producer side of a RingBuffer, which owns the
+// contention-free SingleProducerOffer path. It must be used by one goroutine at a time, and
+// no other producer should offer to the same buffer.
+//
+// With build tag lfringdebug, concurrent use of a SingleProducer panics.
+type SingleProducer[T any] interface {
+	Producer[T]
+	SingleProducerOffer(valueSupplier func() (v T, finish bool))
+}
+
+// SingleConsumer is the exclusive consumer side of a RingBuffer, which owns the
+// contention-free SingleConsumerPoll / SingleConsumerPollVec path. It must be used by one
+// goroutine at a time, and no other consumer should poll from the same buffer. Its All and
+// Stream also poll by the contention-free path.
+//
+// With build tag lfringdebug, concurrent use of a SingleConsumer panics.
+type SingleConsumer[T any] interface {
+	Consumer[T]
+	SingleConsumerPoll(valueConsumer func(T))
+	SingleConsumerPollVec(ret []T) (validCnt uint64)
+}
+
+// ProducerOf returns the Producer of buffer.
+func ProducerOf[T any](buffer RingBuffer[T]) Producer[T] {
+	return &producer[T]{buffer: buffer}
+}
+
+// ConsumerOf returns the Consumer of buffer.
+func ConsumerOf[T any](buffer RingBuffer[T]) Consumer[T] {
+	return &consumer[T]{buffer: buffer}
+}
+
+// SingleProducerOf returns the SingleProducer of buffer, hand it to the only producer. There is
+// only one SingleProducer for each buffer built by New, calling it twice on the same buffer
+// panics.
+func SingleProducerOf[T any](buffer RingBuffer[T]) SingleProducer[T] {
+	own(buffer, func(o *sideOwners) *uint32 { return &o.producer }, "SingleProducerOf")
+	return &singleProducer[T]{buffer: buffer}
+}
+
+// SingleConsumerOf returns the SingleConsumer of buffer, hand it to the only consumer. There is
+// only one SingleConsumer for each buffer built by New, calling it twice on the same buffer
+// panics.
+func SingleConsumerOf[T any](buffer RingBuffer[T]) SingleConsumer[T] {
+	own(buffer, func(o *sideOwners) *uint32 { return &o.consumer }, "SingleConsumerOf")
+	return &singleConsumer[T]{buffer: buffer}
+}
+
+// sideOwners records whether the single handles of a buffer have been handed out.
+type sideOwners struct {
+	producer uint32
+	consumer uint32
+}
+
+// ownedBuffer is the buffer that records the owners of its single sides, which are all the
+// buffers built by New. The buffers implemented by others can't be checked.
+type ownedBuffer interface {
+	singleOwners() *sideOwners
+}
+
+// own marks the side of buffer owned, panics if it has been owned.
+func own(buffer any, side func(*sideOwners) *uint32, name string) {
+	owned, ok := buffer.(ownedBuffer)
+	if ok && !atomic.CompareAndSwapUint32(side(owned.singleOwners()), 0, 1) {
+		panic("lfring: " + name + " called twice on the same buffer, which has only one owner")
+	}
+}
+
+// producer forwards the shared offer side only, the single handles below additionally guard
+// their side. The buffer is held as mpsc does.
+type producer[T any] struct {
+	buffer RingBuffer[T]
+}
+
+func (p *producer[T]) Offer(value T) (success bool) {
+	return p.buffer.Offer(value)
+}
+
+func (p *producer[T]) OfferVec(values []T) (n int) {
+	return p.buffer.OfferVec(values)
+}
+
+func (p *producer[T]) OfferCtx(ctx context.Context, value T) error {
+	return p.buffer.OfferCtx(ctx, value)
+}
+
+func (p *producer[T]) Close() error {
+	return p.buffer.Close()
+}
+
+func (p *producer[T]) IsClosed() bool {
+	return p.buffer.IsClosed()
+}
+
+type consumer[T any] struct {
+	buffer RingBuffer[T]
+}
+
+func (c *consumer[T]) Poll() (value T, success bool) {
+	return c.buffer.Poll()
+}
+
+func (c *consumer[T]) PollVec(dst []T) (n int) {
+	return c.buffer.PollVec(dst)
+}
+
+func (c *consumer[T]) PollCtx(ctx context.Context) (value T, err error) {
+	return c.buffer.PollCtx(ctx)
+}
+
+func (c *consumer[T]) All() iter.Seq[T] {
+	return c.buffer.All()
+}
+
+func (c *consumer[T]) Stream(ctx context.Context) iter.Seq[T] {
+	return c.buffer.Stream(ctx)
+}
+
+func (c *consumer[T]) IsDrained() bool {
+	return c.buffer.IsDrained()
+}
+
+type singleProducer[T any] struct {
+	buffer RingBuffer[T]
+	guard  exclusiveGuard
+}
+
+func (p *singleProducer[T]) Offer(value T) (success bool) {
+	p.guard.enter("SingleProducer.Offer")
+	defer p.guard.exit()
+	return p.buffer.Offer(value)
+}
+
+func (p *singleProducer[T]) OfferVec(values []T) (n int) {
+	p.guard.enter("SingleProducer.OfferVec")
+	defer p.guard.exit()
+	return p.buffer.OfferVec(values)
+}
+
+func (p *singleProducer[T]) OfferCtx(ctx context.Context, value T) error {
+	p.guard.enter("SingleProducer.OfferCtx")
+	defer p.guard.exit()
+	return p.buffer.OfferCtx(ctx, value)
+}
+
+func (p *singleProducer[T]) SingleProducerOffer(valueSupplier func() (v T, finish bool)) {
+	p.guard.enter("SingleProducer.SingleProducerOffer")
+	defer p.guard.exit()
+	p.buffer.SingleProducerOffer(valueSupplier)
+}
+
+func (p *singleProducer[T]) Close() error {
+	return p.buffer.Close()
+}
+
+func (p *singleProducer[T]) IsClosed() bool {
+	return p.buffer.IsClosed()
+}
+
+type singleConsumer[T any] struct {
+	buffer RingBuffer[T]
+	guard  exclusiveGuard
+}
+
+func (c *singleConsumer[T]) Poll() (value T, success bool) {
+	c.guard.enter("SingleConsumer.Poll")
+	defer c.guard.exit()
+	return c.buffer.Poll()
+}
+
+func (c *singleConsumer[T]) PollVec(dst []T) (n int) {
+	c.guard.enter("SingleConsumer.PollVec")
+	defer c.guard.exit()
+	return c.buffer.PollVec(dst)
+}
+
+func (c *singleConsumer[T]) PollCtx(ctx context.Context) (value T, err error) {
+	c.guard.enter("SingleConsumer.PollCtx")
+	defer c.guard.exit()
+	return c.buffer.PollCtx(ctx)
+}
+
+func (c *singleConsumer[T]) All() iter.Seq[T] {
+	return singleAll[T](c)
+}
+
+func (c *singleConsumer[T]) Stream(ctx context.Context) iter.Seq[T] {
+	return singleStream[T](ctx, c)
+}
+
+func (c *singleConsumer[T]) IsDrained() bool {
+	return c.buffer.IsDrained()
+}
+
+func (c *singleConsumer[T]) SingleConsumerPoll(valueConsumer func(T)) {
+	c.guard.enter("SingleConsumer.SingleConsumerPoll")
+	defer c.guard.exit()
+	c.buffer.SingleConsumerPoll(valueConsumer)
+}
+
+func (c *singleConsumer[T]) SingleConsumerPollVec(ret []T) (validCnt uint64) {
+	c.guard.enter("SingleConsumer.SingleConsumerPollVec")
+	defer c.guard.exit()
+	return c.buffer.SingleConsumerPollVec(ret)
+}
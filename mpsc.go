@@ -0,0 +1,117 @@
+package lfring
+
+import (
+	"context"
+	"iter"
+)
+
+// MPSCBuffer is a multi-producer single-consumer ring buffer: any number of goroutines can
+// offer, but only one goroutine can poll.
+//
+// Compare to RingBuffer, it only exposes the methods that are safe under such usage, so
+// that calling a single-producer method from many producers can never compile.
+type MPSCBuffer[T any] interface {
+	// Offer, OfferVec and OfferCtx are safe with multiple producers.
+	Offer(T) (success bool)
+	OfferVec(values []T) (n int)
+	OfferCtx(ctx context.Context, value T) error
+	// Poll, PollCtx, Drain and DrainVec must be called from one consumer.
+	Poll() (value T, success bool)
+	PollCtx(ctx context.Context) (value T, err error)
+	// Drain polls all the available values by the contention-free path.
+	Drain(valueConsumer func(T))
+	// DrainVec polls at most len(ret) values by the contention-free path.
+	DrainVec(ret []T) (validCnt uint64)
+	// All and Stream drain the buffer by the contention-free path, see RingBuffer.
+	All() iter.Seq[T]
+	Stream(ctx context.Context) iter.Seq[T]
+	Close() error
+	IsClosed() bool
+	IsDrained() bool
+	Len() uint64
+	Cap() uint64
+	IsEmpty() bool
+	IsFull() bool
+}
+
+// NewMPSC build a MPSCBuffer, which is backed by Classical buffer.
+func NewMPSC[T any](capacity uint64, opts ...Option) (MPSCBuffer[T], error) {
+	buffer, err := NewE[T](MPSC, capacity, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mpsc[T]{buffer: buffer}, nil
+}
+
+// mpsc narrows RingBuffer to MPSCBuffer, where the single-consumer methods are renamed to
+// Drain / DrainVec. Like every narrowed view of this package (spmc, and the handles of
+// handle.go), it holds the buffer in a field: an embedded RingBuffer would promote all its
+// methods to the view, and a type assertion on the view would get them back.
+type mpsc[T any] struct {
+	buffer RingBuffer[T]
+}
+
+func (r *mpsc[T]) Offer(value T) (success bool) {
+	return r.buffer.Offer(value)
+}
+
+func (r *mpsc[T]) OfferVec(values []T) (n int) {
+	return r.buffer.OfferVec(values)
+}
+
+func (r *mpsc[T]) OfferCtx(ctx context.Context, value T) error {
+	return r.buffer.OfferCtx(ctx, value)
+}
+
+func (r *mpsc[T]) Poll() (value T, success bool) {
+	return r.buffer.Poll()
+}
+
+func (r *mpsc[T]) PollCtx(ctx context.Context) (value T, err error) {
+	return r.buffer.PollCtx(ctx)
+}
+
+func (r *mpsc[T]) Drain(valueConsumer func(T)) {
+	r.buffer.SingleConsumerPoll(valueConsumer)
+}
+
+func (r *mpsc[T]) DrainVec(ret []T) (validCnt uint64) {
+	return r.buffer.SingleConsumerPollVec(ret)
+}
+
+func (r *mpsc[T]) All() iter.Seq[T] {
+	return singleAll[T](r.buffer)
+}
+
+func (r *mpsc[T]) Stream(ctx context.Context) iter.Seq[T] {
+	return singleStream[T](ctx, r.buffer)
+}
+
+func (r *mpsc[T]) Close() error {
+	return r.buffer.Close()
+}
+
+func (r *mpsc[T]) IsClosed() bool {
+	return r.buffer.IsClosed()
+}
+
+func (r *mpsc[T]) IsDrained() bool {
+	return r.buffer.IsDrained()
+}
+
+func (r *mpsc[T]) Len() uint64 {
+	return r.buffer.Len()
+}
+
+func (r *mpsc[T]) Cap() uint64 {
+	return r.buffer.Cap()
+}
+
+func (r *mpsc[T]) IsEmpty() bool {
+	return r.buffer.IsEmpty()
+}
+
+func (r *mpsc[T]) IsFull() bool {
+	return r.buffer.IsFull()
+}
@@ -1,6 +1,8 @@
 package lfring
 
 import (
+	"context"
+	"iter"
 	atomic "sync/atomic"
 )
 
@@ -36,44 +38,93 @@ import (
 //
 // The another difference between this to the mpsc is we no longer need isEmpty() and isFull()
 // to check the buffer status, if buffer full / empty will lead the producer / consumer never
-// pass the node.step check.
+// pass the node.step check. Except for exact capacity (limit less than the number of nodes),
+// where producer has to check (tail - head) against limit.
 type nodeBased[T any] struct {
-	head      uint64
-	_padding0 [56]byte
-	tail      uint64
-	_padding1 [56]byte
-	mask      uint64
-	_padding2 [56]byte
-	element   []*node[T]
+	head         uint64
+	_padding0    [56]byte
+	tail         uint64
+	_padding1    [56]byte
+	mask         uint64
+	limit        uint64
+	_padding2    [48]byte
+	element      []*node[T]
+	metrics      *Metrics
+	waitStrategy WaitStrategy
+	// guard the single-side methods, only works with build tag lfringdebug
+	producerGuard exclusiveGuard
+	consumerGuard exclusiveGuard
+	// the single handles handed out, see SingleProducerOf / SingleConsumerOf
+	owners sideOwners
 }
 
 type node[T any] struct {
-	step     uint64
-	value    T
-	_padding [40]byte
+	step uint64
+	// the number of Peek reading value, see Peek
+	peekers uint32
+	value   T
 }
 
-func newNodeBased[T any](capacity uint64) RingBuffer[T] {
-	nodes := make([]*node[T], capacity)
-	for i := uint64(0); i < capacity; i++ {
-		nodes[i] = &node[T]{step: i}
+// free reports whether the node can be offered at tail: polled, and not being peeked. The step
+// must be checked before peekers, see Peek.
+func (n *node[T]) free(tail uint64) bool {
+	return atomic.LoadUint64(&n.step) == tail && atomic.LoadUint32(&n.peekers) == 0
+}
+
+// paddedNode makes each node occupy its own cache line.
+type paddedNode[T any] struct {
+	node[T]
+	_padding [32]byte
+}
+
+func newNodeBased[T any](capacity uint64, o *options) RingBuffer[T] {
+	realCapacity := findPowerOfTwo(capacity)
+	limit := realCapacity
+	if o.exactCapacity {
+		limit = capacity
+	}
+
+	nodes := make([]*node[T], realCapacity)
+	if o.paddingOr(true) {
+		for i := uint64(0); i < realCapacity; i++ {
+			nodes[i] = &(&paddedNode[T]{node: node[T]{step: i}}).node
+		}
+	} else {
+		block := make([]node[T], realCapacity)
+		for i := uint64(0); i < realCapacity; i++ {
+			block[i].step = i
+			nodes[i] = &block[i]
+		}
 	}
 
 	return &nodeBased[T]{
-		head:    uint64(0),
-		tail:    uint64(0),
-		mask:    capacity - 1,
-		element: nodes,
+		head:         uint64(0),
+		tail:         uint64(0),
+		mask:         realCapacity - 1,
+		limit:        limit,
+		element:      nodes,
+		metrics:      o.metrics,
+		waitStrategy: o.waitStrategy,
 	}
 }
 
 // Offer a value pointer.
 func (r *nodeBased[T]) Offer(value T) (success bool) {
+	success = r.offer(value)
+	r.metrics.recordOffer(boolToUint64(success))
+	return
+}
+
+func (r *nodeBased[T]) offer(value T) (success bool) {
 	oldTail := atomic.LoadUint64(&r.tail)
+	// exact capacity
+	if r.limit <= r.mask && oldTail-atomic.LoadUint64(&r.head) >= r.limit {
+		return false
+	}
+
 	tailNode := r.element[oldTail&r.mask]
-	oldStep := atomic.LoadUint64(&tailNode.step)
-	// not published yet
-	if oldStep != oldTail {
+	// not polled yet, or being peeked
+	if !tailNode.free(oldTail) {
 		return false
 	}
 
@@ -86,8 +137,62 @@ func (r *nodeBased[T]) Offer(value T) (success bool) {
 	return true
 }
 
+// OfferVec offers values as many as possible, returns the number of values offered.
+//
+// Just like Offer, but rather than claim one node by CAS, we check how many nodes after tail
+// are ready to be offered, and claim all of them by one CAS. Once CAS success, all these
+// nodes are owned by current thread, because no one else can claim them, and consumers
+// never touch a node until its step published.
+//
+// If CAS failed (other producers have moved tail), nothing will be offered, as same as Offer.
+func (r *nodeBased[T]) OfferVec(values []T) (n int) {
+	n = r.offerVec(values)
+	r.metrics.recordOffer(uint64(n))
+	return
+}
+
+func (r *nodeBased[T]) offerVec(values []T) int {
+	oldTail := atomic.LoadUint64(&r.tail)
+	room := uint64(len(values))
+	// exact capacity
+	if r.limit <= r.mask {
+		used := oldTail - atomic.LoadUint64(&r.head)
+		if used >= r.limit {
+			return 0
+		}
+		if r.limit-used < room {
+			room = r.limit - used
+		}
+	}
+
+	n := uint64(0)
+	for ; n < room; n++ {
+		// not polled yet, or being peeked
+		if !r.element[(oldTail+n)&r.mask].free(oldTail + n) {
+			break
+		}
+	}
+
+	if n == 0 || !atomic.CompareAndSwapUint64(&r.tail, oldTail, oldTail+n) {
+		return 0
+	}
+
+	for i := uint64(0); i < n; i++ {
+		tailNode := r.element[(oldTail+i)&r.mask]
+		tailNode.value = values[i]
+		atomic.StoreUint64(&tailNode.step, oldTail+i+1)
+	}
+	return int(n)
+}
+
 // Poll head value pointer.
 func (r *nodeBased[T]) Poll() (value T, success bool) {
+	value, success = r.poll()
+	r.metrics.recordPoll(boolToUint64(success))
+	return
+}
+
+func (r *nodeBased[T]) poll() (value T, success bool) {
 	oldHead := atomic.LoadUint64(&r.head)
 	headNode := r.element[oldHead&r.mask]
 	oldStep := atomic.LoadUint64(&headNode.step)
@@ -105,41 +210,298 @@ func (r *nodeBased[T]) Poll() (value T, success bool) {
 	return value, true
 }
 
+// PollVec polls at most len(dst) values into dst, returns the number of values polled.
+//
+// Same as OfferVec, we check how many nodes after head are published, and claim all of them
+// by one CAS. If CAS failed, nothing will be polled, as same as Poll.
+func (r *nodeBased[T]) PollVec(dst []T) (n int) {
+	n = r.pollVec(dst)
+	r.metrics.recordPoll(uint64(n))
+	return
+}
+
+func (r *nodeBased[T]) pollVec(dst []T) int {
+	oldHead := atomic.LoadUint64(&r.head)
+	n := uint64(0)
+	for ; n < uint64(len(dst)); n++ {
+		// not published yet
+		if atomic.LoadUint64(&r.element[(oldHead+n)&r.mask].step) != oldHead+n+1 {
+			break
+		}
+	}
+
+	if n == 0 || !atomic.CompareAndSwapUint64(&r.head, oldHead, oldHead+n) {
+		return 0
+	}
+
+	for i := uint64(0); i < n; i++ {
+		headNode := r.element[(oldHead+i)&r.mask]
+		dst[i] = headNode.value
+		atomic.StoreUint64(&headNode.step, oldHead+i+r.mask+1)
+	}
+	return int(n)
+}
+
+// OfferCtx a value pointer, wait until success or ctx done.
+func (r *nodeBased[T]) OfferCtx(ctx context.Context, value T) error {
+	return OfferWait[T](ctx, r, value, r.waitStrategy)
+}
+
+// PollCtx head value pointer, wait until success or ctx done.
+func (r *nodeBased[T]) PollCtx(ctx context.Context) (value T, err error) {
+	return PollWait[T](ctx, r, r.waitStrategy)
+}
+
+func (r *nodeBased[T]) All() iter.Seq[T] {
+	return all[T](r)
+}
+
+func (r *nodeBased[T]) Stream(ctx context.Context) iter.Seq[T] {
+	return stream[T](ctx, r)
+}
+
+// SingleProducerOffer is the contention-free version of Offer, only one producer is allowed.
+//
+// As the only producer, we count the nodes from tail that have been polled, and claim all of
+// them by a single CAS on tail up front, which never fails but for a concurrent Close. Then we
+// walk through the claimed nodes until the supplier finished, publish each node by its step,
+// and give back the nodes left by a single add on tail. Since tail is moved before any step is
+// published, head never passes tail, and consumers never treat the buffer as drained before
+// the run is done.
+//
+// Close only sets closedFlag on tail, so we check it before filling each node, that nothing
+// will be offered once Close returned. The value supplied during Close is discarded.
 func (r *nodeBased[T]) SingleProducerOffer(valueSupplier func() (v T, finish bool)) {
-	// TODO: currently just wrapper
-	for {
-		v, finish := valueSupplier()
-		if finish {
-			return
+	r.producerGuard.enter("NodeBased.SingleProducerOffer")
+	defer r.producerGuard.exit()
+
+	oldTail := atomic.LoadUint64(&r.tail)
+	oldHead := atomic.LoadUint64(&r.head)
+	room := uint64(0)
+	// a closed tail is always seen as full
+	for ; oldTail+room-oldHead < r.limit; room++ {
+		// not polled yet, or being peeked
+		if !r.element[(oldTail+room)&r.mask].free(oldTail + room) {
+			break
 		}
+	}
+	if room == 0 || !atomic.CompareAndSwapUint64(&r.tail, oldTail, oldTail+room) {
+		r.metrics.recordOffer(0)
+		return
+	}
 
-		for !r.Offer(v) {
+	n := uint64(0)
+	for ; n < room; n++ {
+		v, finish := valueSupplier()
+		// closed, maybe by the supplier itself
+		if finish || atomic.LoadUint64(&r.tail)&closedFlag != 0 {
+			break
 		}
+		tailNode := r.element[(oldTail+n)&r.mask]
+		tailNode.value = v
+		atomic.StoreUint64(&tailNode.step, oldTail+n+1)
 	}
+	// add rather than store, to keep closedFlag
+	if n < room {
+		atomic.AddUint64(&r.tail, ^(room - n - 1))
+	}
+
+	r.metrics.recordOffer(n)
 }
 
+// SingleConsumerPoll is the contention-free version of Poll, only one consumer is allowed.
+//
+// Same as SingleProducerOffer, we walk through the nodes from head, consume and release each
+// node by its step, then move head by a single store. To make sure the call returns even if
+// producers keep offering, at most one round of the ring will be polled.
 func (r *nodeBased[T]) SingleConsumerPoll(valueConsumer func(T)) {
-	// TODO: currently just wrapper
+	r.consumerGuard.enter("NodeBased.SingleConsumerPoll")
+	defer r.consumerGuard.exit()
+
+	oldHead := r.head
+	currHead := oldHead
+	for ; currHead-oldHead <= r.mask; currHead++ {
+		headNode := r.element[currHead&r.mask]
+		// not published yet
+		if atomic.LoadUint64(&headNode.step) != currHead+1 {
+			break
+		}
+
+		valueConsumer(headNode.value)
+		atomic.StoreUint64(&headNode.step, currHead+r.mask+1)
+	}
+
+	atomic.StoreUint64(&r.head, currHead)
+	r.metrics.recordPoll(currHead - oldHead)
+}
+
+// SingleConsumerPollVec is the vectorized version of SingleConsumerPoll, polls at most
+// len(ret) values.
+func (r *nodeBased[T]) SingleConsumerPollVec(ret []T) (validCnt uint64) {
+	r.consumerGuard.enter("NodeBased.SingleConsumerPollVec")
+	defer r.consumerGuard.exit()
+
+	oldHead := r.head
+	currHead := oldHead
+	for ; currHead-oldHead < uint64(len(ret)); currHead++ {
+		headNode := r.element[currHead&r.mask]
+		// not published yet
+		if atomic.LoadUint64(&headNode.step) != currHead+1 {
+			break
+		}
+
+		ret[currHead-oldHead] = headNode.value
+		atomic.StoreUint64(&headNode.step, currHead+r.mask+1)
+	}
+
+	atomic.StoreUint64(&r.head, currHead)
+	r.metrics.recordPoll(currHead - oldHead)
+
+	return currHead - oldHead
+}
+
+// Peek reads the value of the head node, which is safe with multiple consumers. The node is
+// pinned by peekers while we read, and producers never offer to a pinned node. So as long as
+// the step still shows the node published after pinned, the value stays intact until unpinned.
+// If it has been released meanwhile, head must have moved, we just read again.
+func (r *nodeBased[T]) Peek() (value T, success bool) {
 	for {
-		v, success := r.Poll()
-		if !success {
+		oldHead := atomic.LoadUint64(&r.head)
+		headNode := r.element[oldHead&r.mask]
+		atomic.AddUint32(&headNode.peekers, 1)
+		// not published yet, or released
+		if atomic.LoadUint64(&headNode.step) == oldHead+1 {
+			value, success = headNode.value, true
+		}
+		atomic.AddUint32(&headNode.peekers, ^uint32(0))
+
+		if success || atomic.LoadUint64(&r.head) == oldHead {
 			return
 		}
-		valueConsumer(v)
 	}
 }
 
-func (r *nodeBased[T]) SingleConsumerPollVec(ret []T) (end uint64) {
-	// TODO: currently just wrapper
-	var cnt int
-	for ; cnt < len(ret); cnt++ {
-		v, success := r.Poll()
-		if !success {
+func (r *nodeBased[T]) PeekVec(dst []T) (n int) {
+	r.consumerGuard.enter("NodeBased.PeekVec")
+	defer r.consumerGuard.exit()
+
+	oldHead := r.head
+	for ; n < len(dst); n++ {
+		currNode := r.element[(oldHead+uint64(n))&r.mask]
+		// not published yet
+		if atomic.LoadUint64(&currNode.step) != oldHead+uint64(n)+1 {
 			break
 		}
+		dst[n] = currNode.value
+	}
+	return
+}
+
+// Claim is the first half of Offer: claims the tail node by CAS, but leaves filling value and
+// publishing step to the caller.
+func (r *nodeBased[T]) Claim() (slot *T, seq uint64, ok bool) {
+	oldTail := atomic.LoadUint64(&r.tail)
+	// exact capacity
+	if r.limit <= r.mask && oldTail-atomic.LoadUint64(&r.head) >= r.limit {
+		r.metrics.recordOffer(0)
+		return
+	}
+
+	tailNode := r.element[oldTail&r.mask]
+	// not polled yet, being peeked, or closed
+	if !tailNode.free(oldTail) ||
+		!atomic.CompareAndSwapUint64(&r.tail, oldTail, oldTail+1) {
+		r.metrics.recordOffer(0)
+		return
+	}
+
+	r.metrics.recordOffer(1)
+	return &tailNode.value, oldTail, true
+}
+
+// Publish is the second half of Offer, announces the node of seq has been offered.
+func (r *nodeBased[T]) Publish(seq uint64) {
+	atomic.StoreUint64(&r.element[seq&r.mask].step, seq+1)
+}
+
+// Acquire is the first half of Poll: claims the head node by CAS, but leaves reading value
+// and releasing step to the caller.
+func (r *nodeBased[T]) Acquire() (slot *T, seq uint64, ok bool) {
+	oldHead := atomic.LoadUint64(&r.head)
+	headNode := r.element[oldHead&r.mask]
+	// not published yet
+	if atomic.LoadUint64(&headNode.step) != oldHead+1 ||
+		!atomic.CompareAndSwapUint64(&r.head, oldHead, oldHead+1) {
+		r.metrics.recordPoll(0)
+		return
+	}
+
+	r.metrics.recordPoll(1)
+	return &headNode.value, oldHead, true
+}
+
+// Release is the second half of Poll, announces the node of seq can be offered again.
+func (r *nodeBased[T]) Release(seq uint64) {
+	atomic.StoreUint64(&r.element[seq&r.mask].step, seq+r.mask+1)
+}
+
+// Close the buffer by mark closedFlag on tail, once tail has been marked, the step of
+// tail node will never equal to tail, so Offer will fail.
+func (r *nodeBased[T]) Close() error {
+	if err := closeTail(&r.tail); err != nil {
+		return err
+	}
+
+	r.waitStrategy.Signal()
+	return nil
+}
 
-		ret[cnt] = v
+func (r *nodeBased[T]) Len() uint64 {
+	return length(&r.tail, &r.head, r.Cap())
+}
+
+func (r *nodeBased[T]) Cap() uint64 {
+	return r.limit
+}
+
+func (r *nodeBased[T]) IsEmpty() bool {
+	return r.Len() == 0
+}
+
+func (r *nodeBased[T]) IsFull() bool {
+	return r.Len() == r.Cap()
+}
+
+func (r *nodeBased[T]) IsClosed() bool {
+	return atomic.LoadUint64(&r.tail)&closedFlag != 0
+}
+
+func (r *nodeBased[T]) IsDrained() bool {
+	return drained(&r.tail, &r.head)
+}
+
+func (r *nodeBased[T]) singleOwners() *sideOwners {
+	return &r.owners
+}
+
+func (r *nodeBased[T]) strategy() WaitStrategy {
+	return r.waitStrategy
+}
+
+// reset makes the buffer empty and ready to be offered from base, as if it has been offered
+// and polled base values. It must not be called concurrently with any other method.
+//
+// As head and tail never go backward, a caller that holds a stale view of the buffer will
+// definitely fail its CAS after reset, rather than mistake the reset buffer as the old one.
+func (r *nodeBased[T]) reset(base uint64) {
+	var empty T
+	for i := uint64(0); i <= r.mask; i++ {
+		n := r.element[i]
+		n.value = empty
+		// the first index after base that lands on node i
+		atomic.StoreUint64(&n.step, base+((i-base)&r.mask))
 	}
 
-	return uint64(cnt)
+	atomic.StoreUint64(&r.head, base)
+	atomic.StoreUint64(&r.tail, base)
 }
@@ -0,0 +1,158 @@
+package lfring
+
+import "sync/atomic"
+
+// Option configures the buffer built by New / NewE.
+type Option func(*options)
+
+// options holds all the configurations of a buffer, every Option modifies one of them.
+type options struct {
+	exactCapacity bool
+	padding       *bool
+	waitStrategy  WaitStrategy
+	metrics       *Metrics
+	shards        int
+}
+
+// buildOptions apply opts on the default options.
+func buildOptions(opts []Option) *options {
+	o := &options{
+		waitStrategy: defaultWaitStrategy,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// paddingOr returns the padding configuration, or defaultPadding if not configured.
+func (o *options) paddingOr(defaultPadding bool) bool {
+	if o.padding == nil {
+		return defaultPadding
+	}
+	return *o.padding
+}
+
+// WithExactCapacity makes the buffer hold exactly the given capacity of values, rather than
+// the capacity rounded up to power of two. The underlying array is still power of two, so
+// it doesn't save memory, but gives precise backpressure.
+func WithExactCapacity() Option {
+	return func(o *options) {
+		o.exactCapacity = true
+	}
+}
+
+// WithCacheLinePadding controls whether each element of the buffer occupies its own cache
+// line, which avoids false sharing between producers and consumers that working on adjacent
+// elements, at the cost of memory.
+//
+// NodeBased is padded by default, Classical is not. SPSC ignores it, as only one producer
+// and one consumer work on its elements.
+func WithCacheLinePadding(padding bool) Option {
+	return func(o *options) {
+		o.padding = &padding
+	}
+}
+
+// WithWaitStrategy set the WaitStrategy used by OfferCtx and PollCtx, by default they spin,
+// then yield, then park.
+func WithWaitStrategy(strategy WaitStrategy) Option {
+	return func(o *options) {
+		o.waitStrategy = strategy
+	}
+}
+
+// WithMetrics makes the buffer record its operations to m. The counters are shared among all
+// producers and consumers, which brings extra contention, so only enable it when needed.
+func WithMetrics(m *Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithShards set the number of shards of Sharded buffer, by default (or n <= 0) it's
+// GOMAXPROCS. Other buffer types ignore it.
+func WithShards(n int) Option {
+	return func(o *options) {
+		o.shards = n
+	}
+}
+
+// Metrics counts the operations of buffers, it can be shared among several buffers.
+type Metrics struct {
+	offered      uint64
+	offerFailure uint64
+	polled       uint64
+	pollFailure  uint64
+	dropped      uint64
+}
+
+// Offered is the number of values that have been offered.
+func (m *Metrics) Offered() uint64 {
+	return atomic.LoadUint64(&m.offered)
+}
+
+// OfferFailures is the number of Offer calls that offered nothing (buffer full or closed).
+func (m *Metrics) OfferFailures() uint64 {
+	return atomic.LoadUint64(&m.offerFailure)
+}
+
+// Polled is the number of values that have been polled.
+func (m *Metrics) Polled() uint64 {
+	return atomic.LoadUint64(&m.polled)
+}
+
+// PollFailures is the number of Poll calls that polled nothing (buffer empty).
+func (m *Metrics) PollFailures() uint64 {
+	return atomic.LoadUint64(&m.pollFailure)
+}
+
+// Dropped is the number of values that have been evicted by Overwrite buffer to make room
+// for the new ones.
+func (m *Metrics) Dropped() uint64 {
+	return atomic.LoadUint64(&m.dropped)
+}
+
+// recordOffer records an offer that offered n values, it's safe to be called with nil m.
+func (m *Metrics) recordOffer(n uint64) {
+	if m == nil {
+		return
+	}
+
+	if n == 0 {
+		atomic.AddUint64(&m.offerFailure, 1)
+	} else {
+		atomic.AddUint64(&m.offered, n)
+	}
+}
+
+// recordPoll records a poll that polled n values, it's safe to be called with nil m.
+func (m *Metrics) recordPoll(n uint64) {
+	if m == nil {
+		return
+	}
+
+	if n == 0 {
+		atomic.AddUint64(&m.pollFailure, 1)
+	} else {
+		atomic.AddUint64(&m.polled, n)
+	}
+}
+
+// recordDrop records n values evicted, it's safe to be called with nil m.
+func (m *Metrics) recordDrop(n uint64) {
+	if m == nil {
+		return
+	}
+
+	atomic.AddUint64(&m.dropped, n)
+}
+
+// boolToUint64 converts success to the number of values for metrics.
+func boolToUint64(success bool) uint64 {
+	if success {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,193 @@
+package lfring
+
+import (
+	"context"
+	"iter"
+	"sync/atomic"
+)
+
+// OverwriteBuffer is a lossy ring buffer works like a flight recorder: Offer never fails
+// because of full, instead the oldest value will be evicted to make room for the new one.
+// It only fails once the buffer has been closed.
+type OverwriteBuffer[T any] interface {
+	RingBuffer[T]
+	// Dropped is the number of values that have been evicted by Offer.
+	Dropped() uint64
+}
+
+// NewOverwrite build an OverwriteBuffer, which is backed by NodeBased buffer.
+func NewOverwrite[T any](capacity uint64, opts ...Option) (OverwriteBuffer[T], error) {
+	buffer, err := NewE[T](Overwrite, capacity, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.(OverwriteBuffer[T]), nil
+}
+
+// overwrite evicts the oldest value by Poll when Offer finds the buffer full, then tries to
+// offer again.
+//
+// Evicting makes producers become consumers of the underlying buffer, so there are always
+// multiple consumers, that's why the single-consumer methods fall back to the multi-consumer
+// ones. For the same reason, a value is either polled by consumer or evicted by producer,
+// never both.
+//
+// Under contention, a producer may lose the evicted room to another producer and has to
+// evict again, but each evicted value is counted once in dropped, and Offer still never
+// fails unless closed.
+type overwrite[T any] struct {
+	buffer       *nodeBased[T]
+	dropped      uint64
+	metrics      *Metrics
+	waitStrategy WaitStrategy
+	// the single handles handed out, see SingleProducerOf / SingleConsumerOf
+	owners sideOwners
+}
+
+func newOverwrite[T any](capacity uint64, o *options) RingBuffer[T] {
+	return &overwrite[T]{
+		buffer:       newNodeBased[T](capacity, o).(*nodeBased[T]),
+		metrics:      o.metrics,
+		waitStrategy: o.waitStrategy,
+	}
+}
+
+// Offer a value, evicts the oldest one if the buffer is full. It returns false only if the
+// buffer has been closed.
+func (r *overwrite[T]) Offer(value T) (success bool) {
+	success = r.offer(value)
+	r.metrics.recordOffer(boolToUint64(success))
+	return
+}
+
+func (r *overwrite[T]) offer(value T) bool {
+	for {
+		if r.buffer.offer(value) {
+			return true
+		}
+
+		if r.buffer.IsClosed() {
+			return false
+		}
+
+		// offer may also fail because of contention, only evict when it's really full
+		if !r.buffer.IsFull() {
+			continue
+		}
+
+		if _, success := r.buffer.poll(); success {
+			atomic.AddUint64(&r.dropped, 1)
+			r.metrics.recordDrop(1)
+		}
+	}
+}
+
+// OfferVec offers values one by one, as each Offer always succeeds unless closed.
+func (r *overwrite[T]) OfferVec(values []T) (n int) {
+	for ; n < len(values); n++ {
+		if !r.offer(values[n]) {
+			break
+		}
+	}
+
+	r.metrics.recordOffer(uint64(n))
+	return
+}
+
+// OfferCtx never waits as Offer always succeeds unless closed, in which case ErrClosed will
+// be returned.
+func (r *overwrite[T]) OfferCtx(ctx context.Context, value T) error {
+	return OfferWait[T](ctx, r, value, r.waitStrategy)
+}
+
+// SingleProducerOffer offers values from supplier until it finished or the buffer closed.
+// There is no contention-free path, as producers always contend with each other on eviction.
+func (r *overwrite[T]) SingleProducerOffer(valueSupplier func() (v T, finish bool)) {
+	cnt := uint64(0)
+	for {
+		v, finish := valueSupplier()
+		if finish || !r.offer(v) {
+			break
+		}
+		cnt++
+	}
+
+	r.metrics.recordOffer(cnt)
+}
+
+func (r *overwrite[T]) Poll() (value T, success bool) {
+	return r.buffer.Poll()
+}
+
+func (r *overwrite[T]) PollVec(dst []T) (n int) {
+	return r.buffer.PollVec(dst)
+}
+
+func (r *overwrite[T]) PollCtx(ctx context.Context) (value T, err error) {
+	return PollWait[T](ctx, r, r.waitStrategy)
+}
+
+func (r *overwrite[T]) All() iter.Seq[T] {
+	return all[T](r)
+}
+
+func (r *overwrite[T]) Stream(ctx context.Context) iter.Seq[T] {
+	return stream[T](ctx, r)
+}
+
+// SingleConsumerPoll polls at most one round of the ring by Poll, as producers may evict
+// values concurrently.
+func (r *overwrite[T]) SingleConsumerPoll(valueConsumer func(T)) {
+	cnt := uint64(0)
+	for ; cnt <= r.buffer.mask; cnt++ {
+		v, success := r.buffer.poll()
+		if !success {
+			break
+		}
+		valueConsumer(v)
+	}
+
+	r.metrics.recordPoll(cnt)
+}
+
+// SingleConsumerPollVec is the same as PollVec, as producers may evict values concurrently.
+func (r *overwrite[T]) SingleConsumerPollVec(ret []T) (validCnt uint64) {
+	return uint64(r.buffer.PollVec(ret))
+}
+
+func (r *overwrite[T]) Close() error {
+	return r.buffer.Close()
+}
+
+func (r *overwrite[T]) Len() uint64 {
+	return r.buffer.Len()
+}
+
+func (r *overwrite[T]) Cap() uint64 {
+	return r.buffer.Cap()
+}
+
+func (r *overwrite[T]) IsEmpty() bool {
+	return r.buffer.IsEmpty()
+}
+
+func (r *overwrite[T]) IsFull() bool {
+	return r.buffer.IsFull()
+}
+
+func (r *overwrite[T]) Dropped() uint64 {
+	return atomic.LoadUint64(&r.dropped)
+}
+
+func (r *overwrite[T]) IsClosed() bool {
+	return r.buffer.IsClosed()
+}
+
+func (r *overwrite[T]) IsDrained() bool {
+	return r.buffer.IsDrained()
+}
+
+func (r *overwrite[T]) singleOwners() *sideOwners {
+	return &r.owners
+}
@@ -0,0 +1,20 @@
+package lfring
+
+// Peeker is implemented by the buffers that can look at the values at head without polling
+// them, which are Classical, NodeBased (so do MPSC and SPMC built by New) and SPSC. For
+// example, a protocol decoder can peek the next frame header, and decide whether enough data
+// has arrived before Poll.
+//
+//	if peeker, ok := buffer.(lfring.Peeker[Frame]); ok {
+//		header, success := peeker.Peek()
+//	}
+type Peeker[T any] interface {
+	// Peek returns the value at head without polling it, or false if the buffer is empty. It's
+	// safe with multiple consumers (but for SPSC, which has only one), while the value may have
+	// been polled by others once returned.
+	Peek() (value T, success bool)
+	// PeekVec copies at most len(dst) values from head into dst without polling them, returns
+	// the number of values copied. Only the single consumer can call it, just like
+	// SingleConsumerPollVec.
+	PeekVec(dst []T) (n int)
+}
@@ -0,0 +1,204 @@
+package lfring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// maxScheduleLen limits the sum of weights, as the schedule of polling is precomputed.
+const maxScheduleLen = 1 << 16
+
+// ErrInvalidWeights is returned when build a PriorityRing with no lane, or weights that sum
+// more than 65536.
+var ErrInvalidWeights = errors.New("lfring: invalid weights")
+
+// PriorityRing is composed of several RingBuffer as lanes, one lane for each priority, where
+// 0 is the highest priority. It's safe with multiple producers and consumers, as long as the
+// lanes are.
+//
+// Poll drains higher lanes first. To protect lower lanes from starvation, each lane can be
+// weighted: when all lanes are busy, lane i is polled first weights[i] times in every
+// sum(weights) polls, in the order of smooth weighted round-robin (the one used by nginx), so
+// that polls of a lane spread evenly rather than in a burst. If the lane is empty at its
+// turn, Poll falls back to drain higher lanes first.
+type PriorityRing[T any] struct {
+	lanes        []RingBuffer[T]
+	schedule     []int
+	cursor       uint64
+	waitStrategy WaitStrategy
+}
+
+// NewPriorityRing build a PriorityRing with len(weights) lanes, each lane is built by New
+// with t, capacity and opts. Weights of all zero means strict priority: lower lanes are polled
+// only if all higher lanes are empty.
+func NewPriorityRing[T any](t BufferType, capacity uint64, weights []uint64, opts ...Option) (*PriorityRing[T], error) {
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("%w: no lane", ErrInvalidWeights)
+	}
+
+	schedule, err := smoothWeightedSchedule(weights)
+	if err != nil {
+		return nil, err
+	}
+
+	lanes := make([]RingBuffer[T], len(weights))
+	for i := range lanes {
+		if lanes[i], err = NewE[T](t, capacity, opts...); err != nil {
+			return nil, err
+		}
+	}
+
+	return &PriorityRing[T]{
+		lanes:        lanes,
+		schedule:     schedule,
+		waitStrategy: buildOptions(opts).waitStrategy,
+	}, nil
+}
+
+// smoothWeightedSchedule precompute one round of smooth weighted round-robin: in each turn,
+// every lane gains its weight, the lane gained most (the higher one if tie) is picked, then
+// loses the sum of weights. It returns nil if all weights are zero.
+func smoothWeightedSchedule(weights []uint64) ([]int, error) {
+	total := uint64(0)
+	for _, w := range weights {
+		total += w
+		if w > maxScheduleLen || total > maxScheduleLen {
+			return nil, fmt.Errorf("%w: sum of weights should be no more than %d", ErrInvalidWeights, maxScheduleLen)
+		}
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	schedule := make([]int, 0, total)
+	current := make([]int64, len(weights))
+	for len(schedule) < int(total) {
+		picked := 0
+		for i, w := range weights {
+			current[i] += int64(w)
+			if current[i] > current[picked] {
+				picked = i
+			}
+		}
+		current[picked] -= int64(total)
+		schedule = append(schedule, picked)
+	}
+	return schedule, nil
+}
+
+// Offer value to the lane of priority, it panics if priority is out of [0, Lanes()).
+func (r *PriorityRing[T]) Offer(priority int, value T) (success bool) {
+	return r.lanes[priority].Offer(value)
+}
+
+// OfferCtx is the blocking version of Offer, see RingBuffer.OfferCtx.
+func (r *PriorityRing[T]) OfferCtx(ctx context.Context, priority int, value T) error {
+	return r.lanes[priority].OfferCtx(ctx, value)
+}
+
+// Poll a value from the lane at its turn, or the highest lane that is not empty.
+func (r *PriorityRing[T]) Poll() (value T, success bool) {
+	value, _, success = r.PollPriority()
+	return
+}
+
+// PollPriority is the same as Poll, but also returns the priority of the value.
+func (r *PriorityRing[T]) PollPriority() (value T, priority int, success bool) {
+	turn := -1
+	if r.schedule != nil {
+		turn = r.schedule[(atomic.AddUint64(&r.cursor, 1)-1)%uint64(len(r.schedule))]
+		if value, success = r.lanes[turn].Poll(); success {
+			return value, turn, true
+		}
+	}
+
+	for i, lane := range r.lanes {
+		if i == turn {
+			continue
+		}
+		if value, success = lane.Poll(); success {
+			return value, i, true
+		}
+	}
+	return
+}
+
+// PollCtx is the blocking version of Poll, it returns ErrClosed once all lanes have been
+// closed and drained, see RingBuffer.PollCtx.
+func (r *PriorityRing[T]) PollCtx(ctx context.Context) (value T, err error) {
+	for attempt := 0; ; attempt++ {
+		if v, success := r.Poll(); success {
+			r.waitStrategy.Signal()
+			return v, nil
+		}
+
+		if r.IsDrained() {
+			return value, ErrClosed
+		}
+
+		if err = r.waitStrategy.Wait(ctx, attempt); err != nil {
+			return
+		}
+	}
+}
+
+// Close closes all lanes, returns ErrClosed if it has been closed.
+// With SPSC lanes, it must be called by the producer, or after the producer stopped.
+func (r *PriorityRing[T]) Close() (err error) {
+	for _, lane := range r.lanes {
+		if closeErr := lane.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return
+}
+
+// Lanes is the number of lanes, which is also the number of priorities.
+func (r *PriorityRing[T]) Lanes() int {
+	return len(r.lanes)
+}
+
+// Len is the sum of Len of all lanes.
+func (r *PriorityRing[T]) Len() (n uint64) {
+	for _, lane := range r.lanes {
+		n += lane.Len()
+	}
+	return
+}
+
+// Cap is the sum of Cap of all lanes, but no more than MaxCapacity.
+func (r *PriorityRing[T]) Cap() (n uint64) {
+	for _, lane := range r.lanes {
+		if lane.Cap() > MaxCapacity-n {
+			return MaxCapacity
+		}
+		n += lane.Cap()
+	}
+	return
+}
+
+func (r *PriorityRing[T]) IsEmpty() bool {
+	return r.Len() == 0
+}
+
+// IsClosed reports whether all lanes have been closed.
+func (r *PriorityRing[T]) IsClosed() bool {
+	for _, lane := range r.lanes {
+		if !lane.IsClosed() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsDrained reports whether all lanes have been closed and drained.
+func (r *PriorityRing[T]) IsDrained() bool {
+	for _, lane := range r.lanes {
+		if !lane.IsDrained() {
+			return false
+		}
+	}
+	return true
+}
@@ -1,14 +1,133 @@
 package lfring
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"sync/atomic"
+	"unsafe"
+)
+
 // RingBuffer defines the behavior of ring buffer
+//
+// Ordering: except Sharded, all buffer types are FIFO in the order that producers claim the
+// tail, which gives the guarantees below, no matter how many producers and consumers there
+// are:
+//
+//   - per-producer FIFO: values offered by the same producer are polled in the same order.
+//   - real-time FIFO: if Offer(x) returns before Offer(y) starts, then x is never polled by a
+//     Poll that starts after the Poll of y returns.
+//
+// But it's not strictly linearizable: a Poll may fail while a later offered value has been
+// published, if an earlier claimed value is still being written by a slow producer.
 type RingBuffer[T any] interface {
 	Offer(T) (success bool)
+	// Poll returns false both when the buffer is empty for now and when it has been closed and
+	// drained, tell them apart by IsDrained, just like v, ok := <-ch.
 	Poll() (value T, success bool)
+	// OfferVec offers values in a batch, returns the number of values offered from the start
+	// of values, which may be less than len(values) if the buffer doesn't have enough room, or
+	// 0 if the buffer is full or other producers win the contention.
+	OfferVec(values []T) (n int)
+	// PollVec polls values in a batch into dst, returns the number of values polled, which
+	// never exceeds len(dst). Unlike SingleConsumerPollVec, it's safe with multiple consumers.
+	PollVec(dst []T) (n int)
+	// OfferCtx is the blocking version of Offer, it waits until the value has been offered
+	// or ctx is done, in which case ctx.Err() will be returned.
+	OfferCtx(ctx context.Context, value T) error
+	// PollCtx is the blocking version of Poll, it waits until a value has been polled
+	// or ctx is done, in which case ctx.Err() will be returned.
+	PollCtx(ctx context.Context) (value T, err error)
+	// Close closes the buffer, after that all Offer will fail, but the remaining values can
+	// still be polled. Once the buffer has been drained, PollCtx returns ErrClosed.
+	Close() error
+	// IsClosed reports whether the buffer has been closed.
+	IsClosed() bool
+	// IsDrained reports whether the buffer has been closed and all values have been polled,
+	// which is the end of stream: nothing can be polled from it any more.
+	IsDrained() bool
+	// All drains the buffer by Poll until it's empty, e.g. for v := range buffer.All() {}
+	All() iter.Seq[T]
+	// Stream drains the buffer by PollCtx until it's closed and drained or ctx done, e.g.
+	// for v := range buffer.Stream(ctx) {}
+	Stream(ctx context.Context) iter.Seq[T]
 	SingleProducerOffer(valueSupplier func() (v T, finish bool))
 	SingleConsumerPoll(valueConsumer func(T))
 	SingleConsumerPollVec(ret []T) (validCnt uint64)
+	// Len, Cap, IsEmpty and IsFull are introspection of the buffer. Under concurrency, they
+	// are just snapshots that may already be stale once returned, which are good enough for
+	// metrics or backpressure decisions, but never use them to predict the result of Offer
+	// or Poll.
+	//
+	// Len is the number of values that have been offered but not polled yet, includes the ones
+	// in the middle of Offer / Poll.
+	Len() uint64
+	// Cap is the max number of values the buffer can hold.
+	Cap() uint64
+	IsEmpty() bool
+	IsFull() bool
+}
+
+// ErrClosed is returned when offer to a closed buffer, or poll from a closed buffer that
+// has been drained, or close a buffer twice.
+var ErrClosed = errors.New("lfring: buffer closed")
+
+// closedFlag is set on the MSB of tail once the buffer has been closed.
+//
+// The reason we put it into tail rather than a standalone flag is that any producer loaded
+// tail before Close will definitely fail its CAS-tail after Close, hence nothing can be
+// offered once Close returned, and consumers are safe to treat "closed and head reached
+// tail" as end of stream.
+//
+// The cost is that tail can only count up to 2^63, which is far beyond the lifetime of
+// any buffer.
+const closedFlag = uint64(1) << 63
+
+// closeTail set closedFlag on tail, return ErrClosed if it has been set already.
+func closeTail(tail *uint64) error {
+	for {
+		oldTail := atomic.LoadUint64(tail)
+		if oldTail&closedFlag != 0 {
+			return ErrClosed
+		}
+
+		if atomic.CompareAndSwapUint64(tail, oldTail, oldTail|closedFlag) {
+			return nil
+		}
+	}
+}
+
+// drained check whether the buffer has been closed and all offered values have been polled.
+//
+// It relies on every offer, including the single-producer ones, moving tail before publishing
+// the value, so that a value still being written is never missed.
+func drained(tail *uint64, head *uint64) bool {
+	currTail := atomic.LoadUint64(tail)
+	return currTail&closedFlag != 0 && atomic.LoadUint64(head) >= currTail&^closedFlag
 }
 
+// length calculate the number of values between head and tail, limit by capacity.
+//
+// Load head before tail makes sure we never see a tail smaller than head, but as both of them
+// keep moving in between, the result may exceed capacity, so we cap it.
+func length(tail *uint64, head *uint64, capacity uint64) uint64 {
+	currHead := atomic.LoadUint64(head)
+	currTail := atomic.LoadUint64(tail) &^ closedFlag
+	if currTail <= currHead {
+		return 0
+	}
+
+	if currTail-currHead > capacity {
+		return capacity
+	}
+	return currTail - currHead
+}
+
+// cacheLineSize is the most common size of CPU cache line
+const cacheLineSize = 64
+
 // BufferType contains different type names of ring buffer
 type BufferType int
 
@@ -19,20 +138,139 @@ const (
 	// NodeBased is a type of ring buffer that implemented as node based,
 	// see https://www.1024cores.net/home/lock-free-algorithms/queues/bounded-mpmc-queue
 	NodeBased
+
+	// SPSC is a single-producer single-consumer ring buffer (Lamport queue) without any CAS,
+	// all of its methods are single-side: only one goroutine can offer, and only one goroutine
+	// can poll.
+	SPSC
+
+	// MPSC is a multi-producer single-consumer ring buffer backed by Classical. Built by New it
+	// is just a Classical, use NewMPSC to get a MPSCBuffer that only exposes the safe methods.
+	MPSC
+
+	// SPMC is a single-producer multi-consumer ring buffer backed by Classical. Built by New it
+	// is just a Classical, use NewSPMC to get a SPMCBuffer that only exposes the safe methods.
+	SPMC
+
+	// Overwrite is a lossy multi-producer multi-consumer ring buffer backed by NodeBased, its
+	// Offer never fails because of full, but evicts the oldest value instead. Use NewOverwrite
+	// to get an OverwriteBuffer that also reports the number of evicted values.
+	Overwrite
+
+	// Unbounded is a multi-producer multi-consumer queue that never becomes full, it chains
+	// NodeBased buffers as segments, the capacity given to New is the capacity of each segment.
+	Unbounded
+
+	// Growable is a multi-producer multi-consumer ring buffer whose capacity can grow online. Use
+	// NewGrowable to get a GrowableBuffer that can be resized.
+	Growable
+
+	// Sharded is a multi-producer multi-consumer ring buffer that spreads values over several
+	// NodeBased buffers (shards) to reduce contention, at the cost of FIFO: values are only
+	// ordered within a shard. The capacity given to New is shared by all shards, see
+	// WithShards for the number of shards.
+	Sharded
 )
 
-// New build a RingBuffer with BufferType and capacity.
-// Expand capacity as power-of-two, to make head/tail calculate faster and simpler
-func New[T any](t BufferType, capacity uint64) RingBuffer[T] {
-	realCapacity := findPowerOfTwo(capacity)
+const (
+	// MinCapacity is the min capacity of ring buffer
+	MinCapacity = uint64(2)
+	// MaxCapacity is the max capacity of ring buffer. It leaves the MSB of head / tail to
+	// closedFlag, and one more bit for the capacity rounded up to power of two. A capacity within
+	// it can still be too large to allocate, in which case NewE returns ErrInvalidCapacity.
+	MaxCapacity = uint64(1) << 62
+)
+
+// maxBufferBytes is the max size of the array behind a buffer. Beyond that, make panics
+// rather than returns, as it's larger than a single allocation of Go runtime can be (2^48 on
+// 64-bit platforms, math.MaxInt on 32-bit ones).
+const maxBufferBytes = min(uint64(1)<<47, uint64(math.MaxInt))
+
+var (
+	// ErrUnknownBufferType is returned when build a buffer with undefined BufferType
+	ErrUnknownBufferType = errors.New("lfring: unknown buffer type")
+	// ErrInvalidCapacity is returned when build a buffer with capacity out of
+	// [MinCapacity, MaxCapacity], or too large to allocate
+	ErrInvalidCapacity = errors.New("lfring: invalid capacity")
+)
+
+// New build a RingBuffer with BufferType, capacity and options.
+// Expand capacity as power-of-two, to make head/tail calculate faster and simpler.
+//
+// New panics if BufferType or capacity is invalid, use NewE to get an error instead.
+func New[T any](t BufferType, capacity uint64, opts ...Option) RingBuffer[T] {
+	buffer, err := NewE[T](t, capacity, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return buffer
+}
+
+// NewE is the same as New, but returns an error wraps ErrUnknownBufferType or
+// ErrInvalidCapacity rather than panic, for the case that BufferType and capacity
+// come from config.
+func NewE[T any](t BufferType, capacity uint64, opts ...Option) (RingBuffer[T], error) {
+	o := buildOptions(opts)
+	if err := checkCapacity[T](t, capacity, o); err != nil {
+		return nil, err
+	}
 
 	switch t {
 	case NodeBased:
-		return newNodeBased[T](realCapacity)
-	case Classical:
-		return newClassical[T](realCapacity)
+		return newNodeBased[T](capacity, o), nil
+	case Classical, MPSC, SPMC:
+		return newClassical[T](capacity, o), nil
+	case SPSC:
+		return newSPSC[T](capacity, o), nil
+	case Overwrite:
+		return newOverwrite[T](capacity, o), nil
+	case Unbounded:
+		return newUnbounded[T](capacity, o), nil
+	case Growable:
+		return newGrowable[T](capacity, o), nil
+	case Sharded:
+		return newSharded[T](capacity, o), nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownBufferType, t)
+	}
+}
+
+// checkCapacity check whether capacity is in [MinCapacity, MaxCapacity], and the array behind
+// the buffer of type t can be allocated.
+func checkCapacity[T any](t BufferType, capacity uint64, o *options) error {
+	if capacity < MinCapacity || capacity > MaxCapacity {
+		return fmt.Errorf("%w: %d, should be in [%d, %d]", ErrInvalidCapacity, capacity, MinCapacity, MaxCapacity)
+	}
+
+	// classical needs one more slot to hold exact capacity
+	slots := findPowerOfTwo(capacity)
+	if o.exactCapacity {
+		slots = findPowerOfTwo(capacity + 1)
+	}
+
+	if size := elementSize[T](t, o); size != 0 && slots > maxBufferBytes/size {
+		return fmt.Errorf("%w: %d, %d bytes per slot is too large to allocate", ErrInvalidCapacity, capacity, size)
+	}
+	return nil
+}
+
+// elementSize returns how many bytes each slot of the buffer of type t takes, including the
+// padding.
+func elementSize[T any](t BufferType, o *options) uint64 {
+	switch t {
+	case Classical, MPSC, SPMC:
+		return uint64(unsafe.Sizeof(slot[T]{})) << slotShift[T](o)
+	case SPSC:
+		var v T
+		return uint64(unsafe.Sizeof(v))
 	default:
-		panic("shouldn't goes here.")
+		// node based buffers hold a pointer to each node, besides the node itself
+		size := unsafe.Sizeof(node[T]{})
+		if o.paddingOr(true) {
+			size = unsafe.Sizeof(paddedNode[T]{})
+		}
+		return uint64(size + unsafe.Sizeof(uintptr(0)))
 	}
 }
 
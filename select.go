@@ -0,0 +1,88 @@
+package lfring
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// selectCursor rotates the buffer that Select starts from, to make it fair.
+var selectCursor uint64
+
+// Select polls a value from one of bufs, it's the select statement for buffers: bufs are
+// polled in turn from a rotating start, so that no buffer starves others, and once all of
+// them are empty, Select waits by the default strategy (spin, then yield, then park) until
+// any of them has a value, ctx is done, or all of them have been closed and drained.
+//
+// It returns the index of the buffer that the value polled from, or -1 with ctx.Err() or
+// ErrClosed.
+func Select[T any](ctx context.Context, bufs ...RingBuffer[T]) (idx int, value T, err error) {
+	idx, err = selectLoop(ctx, len(bufs), func(i int) (success bool) {
+		value, success = bufs[i].Poll()
+		return
+	}, func(i int) bool {
+		return bufs[i].IsDrained()
+	})
+	return
+}
+
+// SelectCase is a case of SelectFunc, build it by Case.
+type SelectCase struct {
+	poll    func() bool
+	drained func() bool
+}
+
+// Case build a SelectCase that polls from buf, and calls fn with the value polled. It allows
+// SelectFunc to select among buffers of different types.
+func Case[T any](buf RingBuffer[T], fn func(T)) SelectCase {
+	return SelectCase{
+		poll: func() bool {
+			v, success := buf.Poll()
+			if success {
+				fn(v)
+			}
+			return success
+		},
+		drained: buf.IsDrained,
+	}
+}
+
+// SelectFunc is the same as Select, but polls from the buffers of cases, which can be of
+// different types. It returns the index of the case that has been polled, after its callback
+// returns.
+func SelectFunc(ctx context.Context, cases ...SelectCase) (idx int, err error) {
+	return selectLoop(ctx, len(cases), func(i int) bool {
+		return cases[i].poll()
+	}, func(i int) bool {
+		return cases[i].drained()
+	})
+}
+
+// selectLoop tries the n cases in turn until any of them success, or all of them drained.
+func selectLoop(ctx context.Context, n int, try func(i int) bool, drained func(i int) bool) (int, error) {
+	if n == 0 {
+		<-ctx.Done()
+		return -1, ctx.Err()
+	}
+
+	start := int(atomic.AddUint64(&selectCursor, 1) % uint64(n))
+	for attempt := 0; ; attempt++ {
+		allDrained := true
+		for j := 0; j < n; j++ {
+			i := (start + j) % n
+			if try(i) {
+				return i, nil
+			}
+			if allDrained && !drained(i) {
+				allDrained = false
+			}
+		}
+
+		if allDrained {
+			return -1, ErrClosed
+		}
+
+		if err := defaultWaitStrategy.Wait(ctx, attempt); err != nil {
+			return -1, err
+		}
+	}
+}
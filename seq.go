@@ -0,0 +1,70 @@
+package lfring
+
+import (
+	"context"
+	"iter"
+)
+
+// all drains r by Poll until it's empty, safe with multiple consumers.
+func all[T any](r interface{ Poll() (T, bool) }) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, success := r.Poll()
+			if !success || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// stream drains r by PollCtx until it's closed and drained or ctx done, safe with multiple
+// consumers.
+func stream[T any](ctx context.Context, r interface {
+	PollCtx(context.Context) (T, error)
+}) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, err := r.PollCtx(ctx)
+			if err != nil || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// singleConsumerPoller is the consumer that owns the contention-free path.
+type singleConsumerPoller[T any] interface {
+	PollCtx(ctx context.Context) (value T, err error)
+	SingleConsumerPollVec(ret []T) (validCnt uint64)
+}
+
+// singleAll is the same as all, but polls by the contention-free path. It polls one value at
+// a time, as the values polled but not yielded would be lost once the loop breaks.
+func singleAll[T any](r singleConsumerPoller[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		ret := make([]T, 1)
+		for r.SingleConsumerPollVec(ret) == 1 && yield(ret[0]) {
+		}
+	}
+}
+
+// singleStream is the same as stream, but polls by the contention-free path, and waits by
+// PollCtx only if nothing can be polled.
+func singleStream[T any](ctx context.Context, r singleConsumerPoller[T]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		ret := make([]T, 1)
+		for {
+			if r.SingleConsumerPollVec(ret) == 0 {
+				v, err := r.PollCtx(ctx)
+				if err != nil {
+					return
+				}
+				ret[0] = v
+			}
+
+			if !yield(ret[0]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package lfring
+
+import (
+	"context"
+	"iter"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// sharded fans producers out over several NodeBased buffers (shards), to reduce the
+// contention on a single head / tail.
+//
+// Each caller starts from a hinted shard, the hints are held by a sync.Pool, which caches
+// objects per P, so the goroutines running on the same P mostly share the same hint, while
+// goroutines on different Ps work on different shards. Once the hinted shard is full (or
+// empty for consumers), the caller moves to the next shards round-robin, and takes the shard
+// that works as its new hint.
+//
+// Ordering: values in the same shard are FIFO, but there is no order among shards. As a
+// goroutine may migrate between Ps, or move to the next shard once its shard is full, even
+// the values offered by the same producer may be polled out of order.
+type sharded[T any] struct {
+	shards       []*nodeBased[T]
+	hints        sync.Pool
+	nextHint     uint32
+	closed       uint32
+	metrics      *Metrics
+	waitStrategy WaitStrategy
+	// the single handles handed out, see SingleProducerOf / SingleConsumerOf
+	owners sideOwners
+}
+
+// shardHint is the index of shard that a caller starts from.
+type shardHint struct {
+	idx int
+}
+
+// newSharded build a sharded buffer with capacity shared by all shards. Just like other
+// buffer types, capacity is rounded up to power of two unless exact capacity is required,
+// then split into shards with exact capacity, so that Cap is the sum of them. Each shard holds
+// at least MinCapacity values, so there may be fewer shards than configured.
+func newSharded[T any](capacity uint64, o *options) RingBuffer[T] {
+	if !o.exactCapacity {
+		capacity = findPowerOfTwo(capacity)
+	}
+
+	n := uint64(runtime.GOMAXPROCS(0))
+	if o.shards > 0 {
+		n = uint64(o.shards)
+	}
+	if n > capacity/MinCapacity {
+		n = capacity / MinCapacity
+	}
+
+	// shards record nothing, sharded itself does
+	shardOpts := *o
+	shardOpts.metrics = nil
+	shardOpts.exactCapacity = true
+
+	r := &sharded[T]{
+		shards:       make([]*nodeBased[T], n),
+		metrics:      o.metrics,
+		waitStrategy: o.waitStrategy,
+	}
+	for i := range r.shards {
+		shardCap := capacity / n
+		if uint64(i) < capacity%n {
+			shardCap++
+		}
+		r.shards[i] = newNodeBased[T](shardCap, &shardOpts).(*nodeBased[T])
+	}
+	r.hints.New = func() any {
+		return &shardHint{idx: int(atomic.AddUint32(&r.nextHint, 1)-1) % len(r.shards)}
+	}
+	return r
+}
+
+// eachShard calls try on each shard from the hinted one, until try returns true, then takes
+// the shard as the new hint.
+func (r *sharded[T]) eachShard(try func(shard *nodeBased[T]) (done bool)) {
+	hint := r.hints.Get().(*shardHint)
+	for i := 0; i < len(r.shards); i++ {
+		idx := (hint.idx + i) % len(r.shards)
+		if try(r.shards[idx]) {
+			hint.idx = idx
+			break
+		}
+	}
+	r.hints.Put(hint)
+}
+
+func (r *sharded[T]) Offer(value T) (success bool) {
+	r.eachShard(func(shard *nodeBased[T]) bool {
+		success = shard.offer(value)
+		return success
+	})
+	r.metrics.recordOffer(boolToUint64(success))
+	return
+}
+
+// OfferVec offers values to the hinted shard, and the rest to the next shards.
+func (r *sharded[T]) OfferVec(values []T) (n int) {
+	r.eachShard(func(shard *nodeBased[T]) bool {
+		n += shard.offerVec(values[n:])
+		return n == len(values)
+	})
+	r.metrics.recordOffer(uint64(n))
+	return
+}
+
+func (r *sharded[T]) OfferCtx(ctx context.Context, value T) error {
+	return OfferWait[T](ctx, r, value, r.waitStrategy)
+}
+
+// SingleProducerOffer offers by the contention-free path of each shard, moves to the next
+// shard once the current one is full.
+func (r *sharded[T]) SingleProducerOffer(valueSupplier func() (v T, finish bool)) {
+	cnt := uint64(0)
+	finished := false
+	r.eachShard(func(shard *nodeBased[T]) bool {
+		shard.SingleProducerOffer(func() (v T, finish bool) {
+			if v, finish = valueSupplier(); finish {
+				finished = true
+			} else {
+				cnt++
+			}
+			return
+		})
+		return finished
+	})
+	r.metrics.recordOffer(cnt)
+}
+
+func (r *sharded[T]) Poll() (value T, success bool) {
+	r.eachShard(func(shard *nodeBased[T]) bool {
+		value, success = shard.poll()
+		return success
+	})
+	r.metrics.recordPoll(boolToUint64(success))
+	return
+}
+
+// PollVec polls from the hinted shard, then the next shards until dst is full.
+func (r *sharded[T]) PollVec(dst []T) (n int) {
+	r.eachShard(func(shard *nodeBased[T]) bool {
+		n += shard.pollVec(dst[n:])
+		return n == len(dst)
+	})
+	r.metrics.recordPoll(uint64(n))
+	return
+}
+
+func (r *sharded[T]) PollCtx(ctx context.Context) (value T, err error) {
+	return PollWait[T](ctx, r, r.waitStrategy)
+}
+
+func (r *sharded[T]) All() iter.Seq[T] {
+	return all[T](r)
+}
+
+func (r *sharded[T]) Stream(ctx context.Context) iter.Seq[T] {
+	return stream[T](ctx, r)
+}
+
+// SingleConsumerPoll polls by the contention-free path of each shard.
+func (r *sharded[T]) SingleConsumerPoll(valueConsumer func(T)) {
+	cnt := uint64(0)
+	r.eachShard(func(shard *nodeBased[T]) bool {
+		shard.SingleConsumerPoll(func(v T) {
+			valueConsumer(v)
+			cnt++
+		})
+		return false
+	})
+	r.metrics.recordPoll(cnt)
+}
+
+// SingleConsumerPollVec polls by the contention-free path of each shard, until ret is full.
+func (r *sharded[T]) SingleConsumerPollVec(ret []T) (validCnt uint64) {
+	r.eachShard(func(shard *nodeBased[T]) bool {
+		validCnt += shard.SingleConsumerPollVec(ret[validCnt:])
+		return validCnt == uint64(len(ret))
+	})
+	r.metrics.recordPoll(validCnt)
+	return
+}
+
+func (r *sharded[T]) Close() error {
+	if !atomic.CompareAndSwapUint32(&r.closed, 0, 1) {
+		return ErrClosed
+	}
+
+	for _, shard := range r.shards {
+		_ = closeTail(&shard.tail)
+	}
+	r.waitStrategy.Signal()
+	return nil
+}
+
+// Len is the sum of Len of all shards.
+func (r *sharded[T]) Len() (n uint64) {
+	for _, shard := range r.shards {
+		n += shard.Len()
+	}
+	return
+}
+
+// Cap is the sum of Cap of all shards.
+func (r *sharded[T]) Cap() (n uint64) {
+	for _, shard := range r.shards {
+		n += shard.Cap()
+	}
+	return
+}
+
+func (r *sharded[T]) IsEmpty() bool {
+	return r.Len() == 0
+}
+
+func (r *sharded[T]) IsFull() bool {
+	return r.Len() == r.Cap()
+}
+
+func (r *sharded[T]) IsClosed() bool {
+	return atomic.LoadUint32(&r.closed) != 0
+}
+
+func (r *sharded[T]) IsDrained() bool {
+	if !r.IsClosed() {
+		return false
+	}
+
+	for _, shard := range r.shards {
+		if !shard.IsDrained() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *sharded[T]) singleOwners() *sideOwners {
+	return &r.owners
+}
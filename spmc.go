@@ -0,0 +1,115 @@
+package lfring
+
+import (
+	"context"
+	"iter"
+)
+
+// SPMCBuffer is a single-producer multi-consumer ring buffer: only one goroutine can offer,
+// but any number of goroutines can poll.
+//
+// Compare to RingBuffer, it only exposes the methods that are safe under such usage, so
+// that calling a single-consumer method from many consumers can never compile.
+type SPMCBuffer[T any] interface {
+	// Offer, OfferVec, OfferCtx and Fill must be called from one producer.
+	Offer(T) (success bool)
+	OfferVec(values []T) (n int)
+	OfferCtx(ctx context.Context, value T) error
+	// Fill offers the values from supplier by the contention-free path, until supplier
+	// finished or the buffer is full.
+	Fill(valueSupplier func() (v T, finish bool))
+	// Poll, PollVec and PollCtx are safe with multiple consumers.
+	Poll() (value T, success bool)
+	PollVec(dst []T) (n int)
+	PollCtx(ctx context.Context) (value T, err error)
+	All() iter.Seq[T]
+	Stream(ctx context.Context) iter.Seq[T]
+	Close() error
+	IsClosed() bool
+	IsDrained() bool
+	Len() uint64
+	Cap() uint64
+	IsEmpty() bool
+	IsFull() bool
+}
+
+// NewSPMC build a SPMCBuffer, which is backed by Classical buffer.
+func NewSPMC[T any](capacity uint64, opts ...Option) (SPMCBuffer[T], error) {
+	buffer, err := NewE[T](SPMC, capacity, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &spmc[T]{buffer: buffer}, nil
+}
+
+// spmc is the mirror of mpsc: SingleProducerOffer is exposed as Fill, while the
+// single-consumer methods are left out, as All / Stream of the buffer are safe with multiple
+// consumers already.
+type spmc[T any] struct {
+	buffer RingBuffer[T]
+}
+
+func (r *spmc[T]) Offer(value T) (success bool) {
+	return r.buffer.Offer(value)
+}
+
+func (r *spmc[T]) OfferVec(values []T) (n int) {
+	return r.buffer.OfferVec(values)
+}
+
+func (r *spmc[T]) OfferCtx(ctx context.Context, value T) error {
+	return r.buffer.OfferCtx(ctx, value)
+}
+
+func (r *spmc[T]) Fill(valueSupplier func() (v T, finish bool)) {
+	r.buffer.SingleProducerOffer(valueSupplier)
+}
+
+func (r *spmc[T]) Poll() (value T, success bool) {
+	return r.buffer.Poll()
+}
+
+func (r *spmc[T]) PollVec(dst []T) (n int) {
+	return r.buffer.PollVec(dst)
+}
+
+func (r *spmc[T]) PollCtx(ctx context.Context) (value T, err error) {
+	return r.buffer.PollCtx(ctx)
+}
+
+func (r *spmc[T]) All() iter.Seq[T] {
+	return r.buffer.All()
+}
+
+func (r *spmc[T]) Stream(ctx context.Context) iter.Seq[T] {
+	return r.buffer.Stream(ctx)
+}
+
+func (r *spmc[T]) Close() error {
+	return r.buffer.Close()
+}
+
+func (r *spmc[T]) IsClosed() bool {
+	return r.buffer.IsClosed()
+}
+
+func (r *spmc[T]) IsDrained() bool {
+	return r.buffer.IsDrained()
+}
+
+func (r *spmc[T]) Len() uint64 {
+	return r.buffer.Len()
+}
+
+func (r *spmc[T]) Cap() uint64 {
+	return r.buffer.Cap()
+}
+
+func (r *spmc[T]) IsEmpty() bool {
+	return r.buffer.IsEmpty()
+}
+
+func (r *spmc[T]) IsFull() bool {
+	return r.buffer.IsFull()
+}
@@ -0,0 +1,308 @@
+package lfring
+
+import (
+	"context"
+	"iter"
+	"sync/atomic"
+)
+
+// spsc defines a single-producer single-consumer ring buffer, namely Lamport queue.
+//
+// As there is only one producer and one consumer, tail is only written by the producer and
+// head is only written by the consumer, no CAS is needed at all: the producer writes value
+// then publish it by storing tail, the consumer reads value then release it by storing head.
+//
+// To further reduce the cache line bouncing between producer and consumer, each side keeps
+// a cached copy of the other side's index, which is only refreshed when the cached one shows
+// the buffer full (for producer) or empty (for consumer). The indexes and their cache of each
+// side are put on separate cache lines.
+//
+// Every method of spsc is a single-side method: all the offer methods must be called from
+// one producer goroutine, and all the poll methods must be called from one consumer goroutine.
+// Close belongs to the producer side: it must be called by the producer, or after the
+// producer stopped, same as close a channel. Otherwise a value offered concurrently may be
+// published after consumers have seen the buffer drained.
+type spsc[T any] struct {
+	// consumer side
+	head       uint64
+	cachedTail uint64
+	_padding0  [48]byte
+	// producer side
+	tail       uint64
+	cachedHead uint64
+	filled     uint64
+	end        uint64
+	_padding1  [32]byte
+	// read only
+	mask         uint64
+	limit        uint64
+	closed       uint32
+	element      []T
+	metrics      *Metrics
+	waitStrategy WaitStrategy
+	// guard the single-side methods, only works with build tag lfringdebug
+	producerGuard exclusiveGuard
+	consumerGuard exclusiveGuard
+	// the single handles handed out, see SingleProducerOf / SingleConsumerOf
+	owners sideOwners
+}
+
+func newSPSC[T any](capacity uint64, o *options) RingBuffer[T] {
+	realCapacity := findPowerOfTwo(capacity)
+	limit := realCapacity
+	if o.exactCapacity {
+		limit = capacity
+	}
+
+	return &spsc[T]{
+		mask:         realCapacity - 1,
+		limit:        limit,
+		element:      make([]T, realCapacity),
+		metrics:      o.metrics,
+		waitStrategy: o.waitStrategy,
+	}
+}
+
+func (r *spsc[T]) Offer(value T) (success bool) {
+	r.producerGuard.enter("SPSC.Offer")
+	if atomic.LoadUint32(&r.closed) == 0 && r.room(1) > 0 {
+		r.element[r.tail&r.mask] = value
+		atomic.StoreUint64(&r.tail, r.tail+1)
+		success = true
+	}
+	r.producerGuard.exit()
+
+	r.metrics.recordOffer(boolToUint64(success))
+	return
+}
+
+func (r *spsc[T]) OfferVec(values []T) (n int) {
+	r.producerGuard.enter("SPSC.OfferVec")
+	n = r.offerVec(values)
+	r.producerGuard.exit()
+	r.metrics.recordOffer(uint64(n))
+	return
+}
+
+// SingleProducerOffer writes values to the room, and publish them by a single store of tail
+// once the supplier finished, as offerVec does.
+//
+// The supplier may Close the buffer in the middle, that's why the run is tracked by filled and
+// end rather than local variables: Close publishes the values filled before it, and ends the
+// run. Then the run checks closed once, to not publish again. The value supplied during Close
+// is discarded.
+func (r *spsc[T]) SingleProducerOffer(valueSupplier func() (v T, finish bool)) {
+	r.producerGuard.enter("SPSC.SingleProducerOffer")
+	defer r.producerGuard.exit()
+
+	oldTail := r.tail
+	if atomic.LoadUint32(&r.closed) != 0 {
+		r.metrics.recordOffer(0)
+		return
+	}
+
+	r.filled, r.end = oldTail, oldTail+r.room(r.limit)
+	for r.filled < r.end {
+		v, finish := valueSupplier()
+		// closed by the supplier
+		if finish || r.filled == r.end {
+			break
+		}
+		r.element[r.filled&r.mask] = v
+		r.filled++
+	}
+
+	if atomic.LoadUint32(&r.closed) == 0 {
+		atomic.StoreUint64(&r.tail, r.filled)
+	}
+	r.metrics.recordOffer(r.filled - oldTail)
+}
+
+// offerVec writes values to the room, and publish them by a single store of tail.
+func (r *spsc[T]) offerVec(values []T) int {
+	if atomic.LoadUint32(&r.closed) != 0 {
+		return 0
+	}
+
+	oldTail := r.tail
+	n := r.room(uint64(len(values)))
+	if uint64(len(values)) < n {
+		n = uint64(len(values))
+	}
+
+	for i := uint64(0); i < n; i++ {
+		r.element[(oldTail+i)&r.mask] = values[i]
+	}
+
+	atomic.StoreUint64(&r.tail, oldTail+n)
+	return int(n)
+}
+
+// room returns how many values can be offered, only refresh cachedHead when the cached one
+// shows less room than wanted.
+func (r *spsc[T]) room(want uint64) uint64 {
+	if room := r.limit - (r.tail - r.cachedHead); room >= want {
+		return room
+	}
+
+	r.cachedHead = atomic.LoadUint64(&r.head)
+	return r.limit - (r.tail - r.cachedHead)
+}
+
+func (r *spsc[T]) Poll() (value T, success bool) {
+	r.consumerGuard.enter("SPSC.Poll")
+	if r.available(1) == 0 {
+		r.consumerGuard.exit()
+		r.metrics.recordPoll(0)
+		return
+	}
+
+	value = r.take(r.head)
+	atomic.StoreUint64(&r.head, r.head+1)
+	r.consumerGuard.exit()
+	r.metrics.recordPoll(1)
+	return value, true
+}
+
+func (r *spsc[T]) PollVec(dst []T) (n int) {
+	validCnt := r.SingleConsumerPollVec(dst)
+	return int(validCnt)
+}
+
+func (r *spsc[T]) SingleConsumerPoll(valueConsumer func(T)) {
+	r.consumerGuard.enter("SPSC.SingleConsumerPoll")
+	defer r.consumerGuard.exit()
+
+	oldHead := r.head
+	n := r.available(r.limit)
+	for i := uint64(0); i < n; i++ {
+		valueConsumer(r.take(oldHead + i))
+	}
+
+	atomic.StoreUint64(&r.head, oldHead+n)
+	r.metrics.recordPoll(n)
+}
+
+func (r *spsc[T]) SingleConsumerPollVec(ret []T) (validCnt uint64) {
+	r.consumerGuard.enter("SPSC.SingleConsumerPollVec")
+	defer r.consumerGuard.exit()
+
+	oldHead := r.head
+	n := r.available(uint64(len(ret)))
+	if uint64(len(ret)) < n {
+		n = uint64(len(ret))
+	}
+
+	for i := uint64(0); i < n; i++ {
+		ret[i] = r.take(oldHead + i)
+	}
+
+	atomic.StoreUint64(&r.head, oldHead+n)
+	r.metrics.recordPoll(n)
+	return n
+}
+
+// Peek is single-side for SPSC, as Poll is.
+func (r *spsc[T]) Peek() (value T, success bool) {
+	r.consumerGuard.enter("SPSC.Peek")
+	defer r.consumerGuard.exit()
+
+	if r.available(1) == 0 {
+		return
+	}
+	return r.element[r.head&r.mask], true
+}
+
+func (r *spsc[T]) PeekVec(dst []T) (n int) {
+	r.consumerGuard.enter("SPSC.PeekVec")
+	defer r.consumerGuard.exit()
+
+	cnt := r.available(uint64(len(dst)))
+	for ; n < len(dst) && uint64(n) < cnt; n++ {
+		dst[n] = r.element[(r.head+uint64(n))&r.mask]
+	}
+	return
+}
+
+// available returns how many values can be polled, only refresh cachedTail when the cached
+// one shows less than wanted.
+func (r *spsc[T]) available(want uint64) uint64 {
+	if available := r.cachedTail - r.head; available >= want {
+		return available
+	}
+
+	r.cachedTail = atomic.LoadUint64(&r.tail)
+	return r.cachedTail - r.head
+}
+
+// take the value out of element, and clear it to not hold any reference that prevents GC.
+func (r *spsc[T]) take(i uint64) (value T) {
+	var empty T
+	value, r.element[i&r.mask] = r.element[i&r.mask], empty
+	return
+}
+
+func (r *spsc[T]) OfferCtx(ctx context.Context, value T) error {
+	return OfferWait[T](ctx, r, value, r.waitStrategy)
+}
+
+func (r *spsc[T]) PollCtx(ctx context.Context) (value T, err error) {
+	return PollWait[T](ctx, r, r.waitStrategy)
+}
+
+func (r *spsc[T]) All() iter.Seq[T] {
+	return all[T](r)
+}
+
+func (r *spsc[T]) Stream(ctx context.Context) iter.Seq[T] {
+	return stream[T](ctx, r)
+}
+
+// Close must be called by the producer, see spsc. If it's called by the supplier of
+// SingleProducerOffer, the values filled before are published first.
+func (r *spsc[T]) Close() error {
+	if atomic.LoadUint32(&r.closed) != 0 {
+		return ErrClosed
+	}
+
+	if r.filled > r.tail {
+		atomic.StoreUint64(&r.tail, r.filled)
+	}
+	r.end = r.filled
+	atomic.StoreUint32(&r.closed, 1)
+
+	r.waitStrategy.Signal()
+	return nil
+}
+
+func (r *spsc[T]) Len() uint64 {
+	return length(&r.tail, &r.head, r.Cap())
+}
+
+func (r *spsc[T]) Cap() uint64 {
+	return r.limit
+}
+
+func (r *spsc[T]) IsEmpty() bool {
+	return r.Len() == 0
+}
+
+func (r *spsc[T]) IsFull() bool {
+	return r.Len() == r.Cap()
+}
+
+func (r *spsc[T]) IsClosed() bool {
+	return atomic.LoadUint32(&r.closed) != 0
+}
+
+func (r *spsc[T]) IsDrained() bool {
+	return r.IsClosed() && atomic.LoadUint64(&r.head) >= atomic.LoadUint64(&r.tail)
+}
+
+func (r *spsc[T]) singleOwners() *sideOwners {
+	return &r.owners
+}
+
+func (r *spsc[T]) strategy() WaitStrategy {
+	return r.waitStrategy
+}
@@ -0,0 +1,389 @@
+package lfring
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// unbounded is a queue that chains NodeBased buffers as segments, just like LCRQ or the
+// poolChain of sync.Pool. Producers offer to the tail segment, consumers poll from the head
+// segment, and it never becomes full.
+//
+// 1. Once a producer finds the tail segment full, it seals the segment by closeTail, the same
+// as Close a buffer, so that no one can offer to it anymore, while consumers can still drain
+// it. Then the producer appends a new segment to next of the sealed one by CAS (whoever wins
+// the CAS, the others just follow), and moves tail to it.
+//
+// 2. Once a consumer finds the head segment drained (sealed and empty), it moves head to the
+// next segment, and recycles the drained one to build the next new segment.
+//
+// Each new segment starts from where the sealed one ends (as its base), so head / tail of
+// the segments keep growing across the whole chain, which gives us Len() for free, and makes
+// sure any caller holding a stale view of a recycled segment fails its CAS, rather than
+// offer / poll on it.
+//
+// To recycle a segment safely, each caller holds a reference of the segment during the
+// operation, and validates the segment is still head / tail after it gets the reference. A
+// drained segment is recycled only if no one references it after it has been unlinked from
+// both head and tail, otherwise it's left to GC.
+//
+// Close appends a sentinel segment rather than a real one to the sealed tail segment, which
+// tells both producers and consumers there is nothing after.
+//
+// Once growable, producers never seal a full segment, hence the queue is bounded by the
+// capacity of its tail segment, until Resize seals it and appends a larger one, see Resize.
+type unbounded[T any] struct {
+	head         unsafe.Pointer // *segment[T]
+	_padding0    [56]byte
+	tail         unsafe.Pointer // *segment[T]
+	_padding1    [56]byte
+	closed       uint32
+	growable     bool
+	segmentCap   uint64
+	resizeMu     sync.Mutex
+	sentinel     *segment[T]
+	recycled     sync.Pool
+	segmentOpts  *options
+	metrics      *Metrics
+	waitStrategy WaitStrategy
+	// the single handles handed out, see SingleProducerOf / SingleConsumerOf
+	owners sideOwners
+}
+
+type segment[T any] struct {
+	ring      *nodeBased[T]
+	next      unsafe.Pointer // *segment[T]
+	_padding0 [48]byte
+	refs      int32
+}
+
+func newUnbounded[T any](capacity uint64, o *options) RingBuffer[T] {
+	// segments record nothing, unbounded itself does
+	segmentOpts := *o
+	segmentOpts.metrics = nil
+
+	r := &unbounded[T]{
+		segmentCap:   capacity,
+		sentinel:     &segment[T]{},
+		segmentOpts:  &segmentOpts,
+		metrics:      o.metrics,
+		waitStrategy: o.waitStrategy,
+	}
+	first := r.newSegment(0)
+	// the capacity rounded by segment, so that recycled segments can be matched
+	r.segmentCap = first.ring.limit
+	r.head = unsafe.Pointer(first)
+	r.tail = unsafe.Pointer(first)
+	return r
+}
+
+// newSegment build a segment starts from base, reuse the recycled one if any.
+func (r *unbounded[T]) newSegment(base uint64) *segment[T] {
+	seg, _ := r.recycled.Get().(*segment[T])
+	if seg == nil || seg.ring.limit != atomic.LoadUint64(&r.segmentCap) {
+		seg = &segment[T]{
+			ring: newNodeBased[T](atomic.LoadUint64(&r.segmentCap), r.segmentOpts).(*nodeBased[T]),
+		}
+	}
+
+	seg.ring.reset(base)
+	atomic.StorePointer(&seg.next, nil)
+	return seg
+}
+
+// acquire returns the segment that at is pointing to, with a reference held.
+func (r *unbounded[T]) acquire(at *unsafe.Pointer) *segment[T] {
+	for {
+		seg := (*segment[T])(atomic.LoadPointer(at))
+		atomic.AddInt32(&seg.refs, 1)
+		// validate-after-load, make sure seg hasn't been unlinked before we hold it
+		if atomic.LoadPointer(at) == unsafe.Pointer(seg) {
+			return seg
+		}
+		atomic.AddInt32(&seg.refs, -1)
+	}
+}
+
+func (r *unbounded[T]) release(seg *segment[T]) {
+	atomic.AddInt32(&seg.refs, -1)
+}
+
+// produce calls try on the tail segment until it returns true, seals the tail segment and
+// moves to the next one once it's full. It returns false if the buffer has been closed, or
+// it's growable and full.
+func (r *unbounded[T]) produce(try func(ring *nodeBased[T], room uint64) (done bool)) bool {
+	for {
+		seg := r.acquire(&r.tail)
+		room := r.room(seg)
+		if room == 0 {
+			r.release(seg)
+			return false
+		}
+
+		if try(seg.ring, room) {
+			r.release(seg)
+			return true
+		}
+
+		if !seg.ring.IsClosed() {
+			// failed because of contention, just retry
+			if !seg.ring.IsFull() {
+				r.release(seg)
+				continue
+			}
+			if r.growable {
+				r.release(seg)
+				return false
+			}
+			_ = closeTail(&seg.ring.tail)
+		}
+
+		next := r.appendNext(seg, nil)
+		r.release(seg)
+		if next == r.sentinel {
+			return false
+		}
+	}
+}
+
+// room is how many values can be offered to the tail segment seg besides its own capacity.
+//
+// Once a growable buffer has been resized, the values remaining in the sealed segments also
+// count, so the room is (Cap - Len). It's checked before offer rather than by CAS, so the
+// capacity may be exceeded by concurrent producers while the sealed segments are being
+// drained.
+func (r *unbounded[T]) room(seg *segment[T]) uint64 {
+	if !r.growable || atomic.LoadPointer(&r.head) == unsafe.Pointer(seg) {
+		return MaxCapacity
+	}
+
+	currLen, capacity := r.Len(), r.Cap()
+	if currLen >= capacity {
+		return 0
+	}
+	return capacity - currLen
+}
+
+// appendNext makes sure the sealed seg has a next segment, appends next to it (or a new
+// segment if next is nil) if it hasn't, then moves tail to the next segment of seg and
+// return it.
+func (r *unbounded[T]) appendNext(seg *segment[T], next *segment[T]) *segment[T] {
+	curr := (*segment[T])(atomic.LoadPointer(&seg.next))
+	if curr == nil {
+		if next == nil {
+			next = r.newSegment(atomic.LoadUint64(&seg.ring.tail) &^ closedFlag)
+		}
+		if atomic.CompareAndSwapPointer(&seg.next, nil, unsafe.Pointer(next)) {
+			curr = next
+		} else {
+			// no one else has seen it
+			if next != r.sentinel {
+				r.recycled.Put(next)
+			}
+			curr = (*segment[T])(atomic.LoadPointer(&seg.next))
+		}
+	}
+
+	if curr != r.sentinel {
+		atomic.CompareAndSwapPointer(&r.tail, unsafe.Pointer(seg), unsafe.Pointer(curr))
+	}
+	return curr
+}
+
+// consume calls try on the head segment, moves to the next segment once the head segment
+// has been drained. It returns false if try failed on a segment that is not drained, or
+// there is no next segment.
+func (r *unbounded[T]) consume(try func(ring *nodeBased[T]) (done bool)) bool {
+	for {
+		seg := r.acquire(&r.head)
+		if try(seg.ring) {
+			r.release(seg)
+			return true
+		}
+
+		next := (*segment[T])(atomic.LoadPointer(&seg.next))
+		if !seg.ring.IsDrained() || next == nil || next == r.sentinel {
+			r.release(seg)
+			return false
+		}
+
+		// producer moves tail after appending next, make sure tail never falls behind head
+		atomic.CompareAndSwapPointer(&r.tail, unsafe.Pointer(seg), unsafe.Pointer(next))
+		unlinked := atomic.CompareAndSwapPointer(&r.head, unsafe.Pointer(seg), unsafe.Pointer(next))
+		r.release(seg)
+		// recycle seg only if no one references it, otherwise leave it to GC
+		if unlinked && atomic.LoadInt32(&seg.refs) == 0 {
+			r.recycled.Put(seg)
+		}
+	}
+}
+
+func (r *unbounded[T]) Offer(value T) (success bool) {
+	success = r.produce(func(ring *nodeBased[T], _ uint64) bool {
+		return ring.offer(value)
+	})
+	r.metrics.recordOffer(boolToUint64(success))
+	return
+}
+
+// OfferVec offers all values unless the buffer has been closed. Values are offered to the
+// tail segment by OfferVec, and continue on the next segment once the tail is full.
+func (r *unbounded[T]) OfferVec(values []T) (n int) {
+	r.produce(func(ring *nodeBased[T], room uint64) bool {
+		batch := values[n:]
+		if uint64(len(batch)) > room {
+			batch = batch[:room]
+		}
+		n += ring.offerVec(batch)
+		return n == len(values)
+	})
+	r.metrics.recordOffer(uint64(n))
+	return
+}
+
+func (r *unbounded[T]) OfferCtx(ctx context.Context, value T) error {
+	return OfferWait[T](ctx, r, value, r.waitStrategy)
+}
+
+// SingleProducerOffer offers values from supplier until it finished or the buffer closed.
+func (r *unbounded[T]) SingleProducerOffer(valueSupplier func() (v T, finish bool)) {
+	cnt := uint64(0)
+	for {
+		v, finish := valueSupplier()
+		if finish || !r.produce(func(ring *nodeBased[T], _ uint64) bool { return ring.offer(v) }) {
+			break
+		}
+		cnt++
+	}
+
+	r.metrics.recordOffer(cnt)
+}
+
+func (r *unbounded[T]) Poll() (value T, success bool) {
+	success = r.consume(func(ring *nodeBased[T]) (done bool) {
+		value, done = ring.poll()
+		return
+	})
+	r.metrics.recordPoll(boolToUint64(success))
+	return
+}
+
+// PollVec polls at most len(dst) values from the head segment.
+func (r *unbounded[T]) PollVec(dst []T) (n int) {
+	r.consume(func(ring *nodeBased[T]) bool {
+		n = ring.pollVec(dst)
+		return n > 0
+	})
+	r.metrics.recordPoll(uint64(n))
+	return
+}
+
+func (r *unbounded[T]) PollCtx(ctx context.Context) (value T, err error) {
+	return PollWait[T](ctx, r, r.waitStrategy)
+}
+
+func (r *unbounded[T]) All() iter.Seq[T] {
+	return all[T](r)
+}
+
+func (r *unbounded[T]) Stream(ctx context.Context) iter.Seq[T] {
+	return stream[T](ctx, r)
+}
+
+// SingleConsumerPoll polls at most one segment of values by Poll, as consumers have to
+// contend with each other on moving between segments.
+func (r *unbounded[T]) SingleConsumerPoll(valueConsumer func(T)) {
+	cnt := uint64(0)
+	for ; cnt < atomic.LoadUint64(&r.segmentCap); cnt++ {
+		var v T
+		if !r.consume(func(ring *nodeBased[T]) (done bool) {
+			v, done = ring.poll()
+			return
+		}) {
+			break
+		}
+		valueConsumer(v)
+	}
+
+	r.metrics.recordPoll(cnt)
+}
+
+// SingleConsumerPollVec is the same as PollVec.
+func (r *unbounded[T]) SingleConsumerPollVec(ret []T) (validCnt uint64) {
+	return uint64(r.PollVec(ret))
+}
+
+// Close seals the tail segment and appends the sentinel to it. If some producers append a
+// new segment before that, we just follow and try again.
+func (r *unbounded[T]) Close() error {
+	for {
+		seg := r.acquire(&r.tail)
+		_ = closeTail(&seg.ring.tail)
+		next := r.appendNext(seg, r.sentinel)
+		r.release(seg)
+
+		if next == r.sentinel {
+			if !atomic.CompareAndSwapUint32(&r.closed, 0, 1) {
+				return ErrClosed
+			}
+
+			r.waitStrategy.Signal()
+			return nil
+		}
+	}
+}
+
+// Len is the distance between head of the head segment and tail of the tail segment, as
+// all segments share the same range of head / tail.
+func (r *unbounded[T]) Len() uint64 {
+	headSeg := (*segment[T])(atomic.LoadPointer(&r.head))
+	currHead := atomic.LoadUint64(&headSeg.ring.head)
+	tailSeg := (*segment[T])(atomic.LoadPointer(&r.tail))
+	currTail := atomic.LoadUint64(&tailSeg.ring.tail) &^ closedFlag
+	if currTail <= currHead {
+		return 0
+	}
+	return currTail - currHead
+}
+
+// Cap is MaxCapacity as the buffer never becomes full, unless it's growable, in which case
+// the capacity of the tail segment.
+func (r *unbounded[T]) Cap() uint64 {
+	if r.growable {
+		return atomic.LoadUint64(&r.segmentCap)
+	}
+	return MaxCapacity
+}
+
+func (r *unbounded[T]) IsEmpty() bool {
+	return r.Len() == 0
+}
+
+// IsFull is always false, unless it's growable.
+func (r *unbounded[T]) IsFull() bool {
+	return r.growable && r.Len() >= r.Cap()
+}
+
+func (r *unbounded[T]) IsClosed() bool {
+	return atomic.LoadUint32(&r.closed) != 0
+}
+
+func (r *unbounded[T]) IsDrained() bool {
+	seg := r.acquire(&r.head)
+	defer r.release(seg)
+	return seg.ring.IsDrained() && atomic.LoadPointer(&seg.next) == unsafe.Pointer(r.sentinel)
+}
+
+func (r *unbounded[T]) singleOwners() *sideOwners {
+	return &r.owners
+}
+
+// headIndex is the number of values that have been polled, only for test
+func (r *unbounded[T]) headIndex() uint64 {
+	seg := r.acquire(&r.head)
+	defer r.release(seg)
+	return atomic.LoadUint64(&seg.ring.head)
+}
@@ -0,0 +1,251 @@
+package lfring
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// spinAttempts is how many times a waiting caller retries without giving up the processor
+	spinAttempts = 64
+	// yieldAttempts is how many times a waiting caller yields the processor before parking
+	yieldAttempts = 64
+	// minPark and maxPark bound the exponential parking interval
+	minPark = time.Microsecond
+	maxPark = time.Millisecond
+	// defaultBlockingPark is the max time that Blocking parks a caller once
+	defaultBlockingPark = 10 * time.Millisecond
+)
+
+// WaitStrategy decides how a caller waits between the failed attempts of Offer / Poll.
+//
+// Just like LMAX Disruptor, different strategy trades latency for CPU differently:
+//
+//	BusySpin     lowest latency, burns a whole CPU while waiting
+//	Yielding     low latency, gives other goroutines a chance to run, still keeps CPU busy
+//	BackoffSleep higher latency, almost no CPU when the buffer stays full / empty for long
+//	Blocking     parks the caller until signaled by peers, see Blocking for details
+type WaitStrategy interface {
+	// Wait blocks the caller after the attempt-th (start from 0) consecutive failure, returns
+	// an error (normally ctx.Err()) to stop waiting.
+	Wait(ctx context.Context, attempt int) error
+	// Signal is called after a successful Offer / Poll, to wake up the callers that blocked
+	// in Wait, if any.
+	Signal()
+}
+
+// defaultWaitStrategy is used when no WaitStrategy has been specified
+var defaultWaitStrategy WaitStrategy = progressiveWait{}
+
+// BusySpin retries immediately without giving up the processor.
+type BusySpin struct{}
+
+// Wait returns ctx.Err() once ctx is done.
+func (BusySpin) Wait(ctx context.Context, _ int) error {
+	return ctx.Err()
+}
+
+// Signal does nothing, as BusySpin never blocks.
+func (BusySpin) Signal() {}
+
+// Yielding yields the processor by runtime.Gosched() before retry.
+type Yielding struct{}
+
+// Wait returns ctx.Err() once ctx is done.
+func (Yielding) Wait(ctx context.Context, _ int) error {
+	runtime.Gosched()
+	return ctx.Err()
+}
+
+// Signal does nothing, as Yielding never blocks.
+func (Yielding) Signal() {}
+
+// BackoffSleep sleeps before retry, the sleep interval starts from Min and doubles on each
+// failed attempt, until Max.
+//
+// A non-positive Min or Max means the default 1us or 1ms, so the zero value of BackoffSleep
+// is ready to use. Max less than Min is taken as Min.
+type BackoffSleep struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// Wait returns ctx.Err() once ctx is done.
+func (b BackoffSleep) Wait(ctx context.Context, attempt int) error {
+	return sleepCtx(ctx, backoffInterval(b.Min, b.Max, attempt))
+}
+
+// Signal does nothing, as BackoffSleep never blocks longer than Max.
+func (BackoffSleep) Signal() {}
+
+// Blocking parks the caller until another caller that shares the same Blocking Signal()
+// (by successfully OfferCtx / PollCtx / OfferWait / PollWait, or Close the buffer).
+//
+// As the plain Offer / Poll never Signal(), and a Signal() may happen right between the
+// failed attempt and registering as a waiter, in which case the wakeup is lost, each park is
+// bounded by a max park time. Which means if all the peers use the waiting API, callers are
+// woken up almost immediately, but an unlucky one may still wait the whole max park time.
+//
+// The zero value of Blocking is ready to use, which parks callers for at most 10ms once.
+type Blocking struct {
+	mu      sync.Mutex
+	notify  chan struct{}
+	waiters int32
+	maxPark time.Duration
+}
+
+// NewBlocking build a Blocking strategy parks callers for at most maxPark once, a
+// non-positive maxPark means the default 10ms.
+func NewBlocking(maxPark time.Duration) *Blocking {
+	return &Blocking{maxPark: maxPark}
+}
+
+// Wait returns ctx.Err() once ctx is done.
+func (b *Blocking) Wait(ctx context.Context, _ int) error {
+	b.mu.Lock()
+	// created lazily, to make the zero value usable
+	if b.notify == nil {
+		b.notify = make(chan struct{})
+	}
+	notify := b.notify
+	park := b.maxPark
+	atomic.AddInt32(&b.waiters, 1)
+	b.mu.Unlock()
+	defer atomic.AddInt32(&b.waiters, -1)
+
+	if park <= 0 {
+		park = defaultBlockingPark
+	}
+	timer := time.NewTimer(park)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-notify:
+		return nil
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Signal wakes up all the parked callers. It takes the lock only if there are waiters, so
+// that the caller on the fast path pays only an atomic load.
+func (b *Blocking) Signal() {
+	if atomic.LoadInt32(&b.waiters) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	if b.notify != nil {
+		close(b.notify)
+		// the next Wait creates a new one
+		b.notify = nil
+	}
+	b.mu.Unlock()
+}
+
+// progressiveWait blocks the caller according to how many attempts have failed so far.
+//
+// Lock-free buffer has nothing to be notified on, so the waiting is progressive: at first
+// we simply retry (the other side is most likely in the middle of publishing), then yield
+// the processor to let other goroutines run, and at last park the goroutine on a timer with
+// exponentially growing interval, to avoid burning CPU on an idle buffer.
+type progressiveWait struct{}
+
+// Wait returns ctx.Err() once ctx is done.
+func (progressiveWait) Wait(ctx context.Context, attempt int) error {
+	if attempt < spinAttempts {
+		return ctx.Err()
+	}
+
+	attempt -= spinAttempts
+	if attempt < yieldAttempts {
+		runtime.Gosched()
+		return ctx.Err()
+	}
+
+	return sleepCtx(ctx, backoffInterval(minPark, maxPark, attempt-yieldAttempts))
+}
+
+// Signal does nothing, as progressiveWait never blocks longer than maxPark.
+func (progressiveWait) Signal() {}
+
+// backoffInterval returns min * 2^attempt, but no more than max. Non-positive min or max is
+// replaced by minPark or maxPark, as doubling zero never grows.
+func backoffInterval(min time.Duration, max time.Duration, attempt int) time.Duration {
+	if min <= 0 {
+		min = minPark
+	}
+	if max <= 0 {
+		max = maxPark
+	}
+	if max < min {
+		max = min
+	}
+
+	interval := min
+	for i := 0; i < attempt && interval < max; i++ {
+		interval <<= 1
+	}
+
+	if interval > max {
+		return max
+	}
+	return interval
+}
+
+// sleepCtx sleeps d, returns ctx.Err() if ctx done before that.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// OfferWait keeps offering value to buffer until success, buffer closed or ctx done, waits
+// by strategy between attempts.
+func OfferWait[T any](ctx context.Context, r RingBuffer[T], value T, strategy WaitStrategy) error {
+	for attempt := 0; ; attempt++ {
+		if r.Offer(value) {
+			strategy.Signal()
+			return nil
+		}
+
+		if r.IsClosed() {
+			return ErrClosed
+		}
+
+		if err := strategy.Wait(ctx, attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// PollWait keeps polling buffer until success, buffer drained or ctx done, waits by strategy
+// between attempts.
+//
+// The drained check goes before ctx check, so that a canceled ctx makes a non-blocking
+// Poll which can tell apart "empty for now" and "closed and drained".
+func PollWait[T any](ctx context.Context, r RingBuffer[T], strategy WaitStrategy) (value T, err error) {
+	for attempt := 0; ; attempt++ {
+		if v, success := r.Poll(); success {
+			strategy.Signal()
+			return v, nil
+		}
+
+		if r.IsDrained() {
+			return value, ErrClosed
+		}
+
+		if err = strategy.Wait(ctx, attempt); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package lfring
+
+// ZeroCopy is implemented by NodeBased buffer, which lets producers and consumers fill and
+// read values in place, rather than copy them in Offer / Poll, just like LMAX Disruptor. It's
+// useful when T is a large struct.
+//
+// Both sides work in two phases:
+//
+//	slot, seq, ok := zc.Claim()  // claim the slot at tail
+//	slot.Field = ...             // fill in place
+//	zc.Publish(seq)              // make it visible to consumers
+//
+//	slot, seq, ok := zc.Acquire() // acquire the slot at head
+//	use(slot.Field)               // read in place
+//	zc.Release(seq)               // give the slot back to producers
+//
+// A slot must be published / released exactly once with the seq returned together, and must
+// not be touched after that. As FIFO is kept, a claimed slot that has not been published
+// blocks all consumers behind it, and an acquired slot that has not been released blocks the
+// producers that wrap around to it, so finish the work in between as soon as possible.
+type ZeroCopy[T any] interface {
+	// Claim returns the slot at tail to be filled, or false if the buffer is full, closed, or
+	// other producers win the contention.
+	Claim() (slot *T, seq uint64, ok bool)
+	// Publish the slot of seq returned by Claim.
+	Publish(seq uint64)
+	// Acquire returns the slot at head to be read, or false if the buffer is empty, or other
+	// consumers win the contention.
+	Acquire() (slot *T, seq uint64, ok bool)
+	// Release the slot of seq returned by Acquire.
+	Release(seq uint64)
+}